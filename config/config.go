@@ -24,13 +24,94 @@ func LoadConfig(filename string) (*Config, error) {
 type Exchange struct {
 	ExchangeKey    string `yaml:"exchangeKey"`
 	ExchangeSecret string `yaml:"exchangeSecret"`
+	Passphrase     string `yaml:"passphrase"` // OKX / Bitget 需要
 	Name           string `yaml:"name"`
+	// Label 账户标签，同一交易所配置多个账户时用于区分日志与通知，未设置时默认取 API key 后 4 位
+	Label string `yaml:"label"`
+	// DangerThreshold / AddMultiple 账户级覆盖，为 0 时使用全局配置
+	DangerThreshold float64 `yaml:"dangerThreshold"`
+	AddMultiple     float64 `yaml:"add_multiple"`
+	Scenario        string  `yaml:"scenario"` // name 为 sim 时使用的场景文件
+	// AutoAddMargin 使用交易所自动追加保证金的交易对（目前仅 ByBit 支持），其余交易对按阈值手动追加
+	AutoAddMargin []string `yaml:"autoAddMargin"`
+	// Markets ByBit 需要监控的 category / settleCoin，默认 linear USDT
+	Markets []Market `yaml:"markets"`
+	// Delivery Binance 同时监控币本位（COIN-M）合约
+	Delivery bool `yaml:"delivery"`
+	// Funding 合约钱包不足时从现货 / 资金账户自动划转（Binance / ByBit）
+	Funding Funding `yaml:"funding"`
+	// UID 主账户 UID，ByBit 主账户向子账户划转时需要
+	UID string `yaml:"uid"`
+	// SubAccounts 主账户下需要监控的子账户，主账户 key 用于向子账户划转资金
+	SubAccounts []SubAccount `yaml:"subAccounts"`
+	// Testnet / Demo 使用测试网或模拟交易环境（Binance / ByBit），两者都开启时以 Testnet 为准
+	Testnet bool `yaml:"testnet"`
+	Demo    bool `yaml:"demo"`
+	// Sub 由主账户配置生成的子账户配置才有值
+	Sub *SubAccount `yaml:"-"`
+}
+
+// SubAccount 子账户配置，使用子账户自己的 API key 监控持仓与追加保证金；
+// 主账户 key 只用于划转，无法查询子账户持仓，因此 exchangeKey / exchangeSecret 必填，缺少时启动失败
+type SubAccount struct {
+	Name           string `yaml:"name"`
+	Email          string `yaml:"email"`          // Binance 子账户邮箱
+	UID            string `yaml:"uid"`            // ByBit 子账户 UID
+	ExchangeKey    string `yaml:"exchangeKey"`    // 子账户 API key，必填
+	ExchangeSecret string `yaml:"exchangeSecret"` // 子账户 API secret，必填
+}
+
+// ForSubAccount 生成子账户的交易所配置，除 API key 外继承主账户设置
+func (e Exchange) ForSubAccount(sub SubAccount) Exchange {
+	conf := e
+	conf.ExchangeKey = sub.ExchangeKey
+	conf.ExchangeSecret = sub.ExchangeSecret
+	conf.SubAccounts = nil
+	conf.Sub = &sub
+	return conf
+}
+
+type Funding struct {
+	Enabled    bool     `yaml:"enabled"`
+	From       []string `yaml:"from"`       // 来源钱包，按顺序尝试: spot / funding，默认由交易所决定（Binance: spot, funding；ByBit 统一账户: funding）
+	DailyLimit float64  `yaml:"dailyLimit"` // 每个交易所每日最多划转金额，必须大于 0
+}
+
+// Market ByBit 持仓分类，如 linear USDT、linear USDC、inverse
+type Market struct {
+	Category   string `yaml:"category"`
+	SettleCoin string `yaml:"settleCoin"`
 }
 
 // Monitor 配置结构体
 type Monitor struct {
 	CheckInterval   int64   `yaml:"checkInterval"`
 	DangerThreshold float64 `yaml:"dangerThreshold"`
+	// CrossReduceFraction 全仓账户保证金率超过阈值且无法划转补足时，按该比例减仓保证金占用最大的全仓持仓，0 为只告警
+	CrossReduceFraction float64 `yaml:"crossReduceFraction"`
+	// CrossReduceInterval 两次减仓的最小间隔（秒），默认 60；间隔内及减仓后未重新读取账户风险前不再减仓
+	CrossReduceInterval int64 `yaml:"crossReduceInterval"`
+	// Reclaim 低风险持续一段时间后回收多余的逐仓保证金
+	Reclaim Reclaim `yaml:"reclaim"`
+	// Liquidation 按标记价格到强平价的距离告警与处理，与保证金率阈值独立
+	Liquidation Liquidation `yaml:"liquidation"`
+}
+
+// Reclaim 保证金回收配置，三项都大于 0 时开启
+type Reclaim struct {
+	SafeThreshold float64 `yaml:"safeThreshold"` // 保证金率低于该值视为低风险
+	TargetRatio   float64 `yaml:"targetRatio"`   // 回收后的目标保证金率，应介于 safeThreshold 与 dangerThreshold 之间
+	Duration      int64   `yaml:"duration"`      // 持续低风险多少秒后回收
+}
+
+// Liquidation 强平距离配置，百分比与 ATR 倍数任一低于阈值即触发，ATR 阈值为 0 时不计算 ATR
+type Liquidation struct {
+	WarnPercent   float64 `yaml:"warnPercent"`   // 距强平价小于该百分比时告警，0 为不告警
+	ActionPercent float64 `yaml:"actionPercent"` // 距强平价小于该百分比时无论保证金率多少都立即处理，默认 2，负数关闭
+	WarnATR       float64 `yaml:"warnATR"`       // 距强平价小于该 ATR 倍数时告警
+	ActionATR     float64 `yaml:"actionATR"`     // 距强平价小于该 ATR 倍数时立即处理
+	ATRInterval   string  `yaml:"atrInterval"`   // ATR 使用的 K 线周期，默认 1h
+	ATRPeriod     int     `yaml:"atrPeriod"`     // ATR 周期，默认 14
 }
 
 // Config 整体配置
@@ -1,58 +1,282 @@
 package exchange
 
 import (
-	"fmt"
+	"context"
 	ccxt "github.com/ccxt/ccxt/go/v4"
+	"github.com/gorilla/websocket"
 	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	binanceFapiURL          = "https://fapi.binance.com"
+	binanceStreamURL        = "wss://fstream.binance.com/ws/"
+	binanceTestnetFapiURL   = "https://testnet.binancefuture.com"
+	binanceDemoFapiURL      = "https://demo-fapi.binance.com"
+	binanceTestnetStreamURL = "wss://fstream.binancefuture.com/ws/"
+
+	// USDⓈ-M 每分钟 IP 权重上限 2400，预留部分给保证金操作
+	binanceWeightLimit    = 2400
+	binanceWeightReserved = 400
+	// 现货 / 钱包（sapi）每分钟 IP 权重上限 6000，与合约分开计数
+	binanceSapiWeightLimit = 6000
+	// ccxt 调用无法读取响应头，按接口文档权重计数
+	binancePositionWeight = 5
+	binanceBalanceWeight  = 5
+	binanceMarginWeight   = 1
 )
 
 type Binance struct {
-	Exchange ccxt.Binance
+	Name       string
+	Exchange   ccxt.Binance
+	Delivery   *ccxt.Binance // 币本位合约，未开启时为 nil
+	Key        string
+	Secret     string
+	FapiURL    string
+	SapiURL    string
+	StreamURL  string
+	Client     *http.Client // U 本位合约（fapi）请求
+	SapiClient *http.Client // 钱包与划转（sapi）请求，使用独立的限流器
+	Dialer     *websocket.Dialer
+	Limiter    *RateLimiter // 与 ccxt 共用的 IP 权重限流
+	Brackets   *bracketCache
+
+	deliverySymbols sync.Map // 币本位合约交易对，追加保证金时选择对应实例
+	qtySteps        sync.Map // U 本位交易对 -> 市价单数量步长
 }
 
-func NewBinance(key string, secret string, proxy string) Exchange {
+func NewBinance(conf config.Exchange, proxy string) Exchange {
+	name := accountName("Binance", conf)
+	limiter := NewRateLimiter(name, binanceWeightLimit, binanceWeightReserved, time.Minute)
+	m := &Binance{
+		Name:      name,
+		Exchange:  newCCXTBinance(conf, proxy, "future"),
+		Key:       conf.ExchangeKey,
+		Secret:    conf.ExchangeSecret,
+		FapiURL:   binanceFapiURL,
+		SapiURL:   binanceSapiURL,
+		StreamURL: binanceStreamURL,
+		Client:    limitClient(newHTTPClient(proxy), limiter, observeBinanceWeight),
+		SapiClient: limitClient(newHTTPClient(proxy),
+			NewRateLimiter(name+" sapi", binanceSapiWeightLimit, 0, time.Minute), observeBinanceWeight),
+		Dialer:  newWSDialer(proxy),
+		Limiter: limiter,
+	}
+	m.Brackets = newBracketCache(name, m.fetchBrackets)
+	// 测试网 / 模拟交易没有 sapi，钱包划转不可用
+	switch {
+	case conf.Testnet:
+		m.FapiURL, m.SapiURL, m.StreamURL = binanceTestnetFapiURL, "", binanceTestnetStreamURL
+		log.Printf("[%s] using futures testnet", m.Name)
+	case conf.Demo:
+		m.FapiURL, m.SapiURL, m.StreamURL = binanceDemoFapiURL, "", binanceTestnetStreamURL
+		log.Printf("[%s] using demo trading", m.Name)
+	}
+	if conf.Delivery {
+		delivery := newCCXTBinance(conf, proxy, "delivery")
+		m.Delivery = &delivery
+	}
+	return m
+}
+
+// newCCXTBinance 创建 ccxt Binance 实例，defaultType 为 future（U 本位）或 delivery（币本位）
+func newCCXTBinance(conf config.Exchange, proxy string, defaultType string) ccxt.Binance {
 	exchange := ccxt.NewBinance(map[string]interface{}{
-		"apiKey": key,
-		"secret": secret,
+		"apiKey": conf.ExchangeKey,
+		"secret": conf.ExchangeSecret,
 		"options": map[string]interface{}{
-			"defaultType": "future",
+			"defaultType": defaultType,
 		},
 	})
 	if proxy != "" {
 		exchange.WsProxy = proxy
 		exchange.HttpsProxy = proxy
 	}
-	<-exchange.LoadMarkets()
-	return &Binance{
-		Exchange: exchange,
+	switch {
+	case conf.Testnet:
+		exchange.SetSandboxMode(true)
+	case conf.Demo:
+		exchange.EnableDemoTrading(true)
 	}
+	<-exchange.LoadMarkets()
+	return exchange
 }
 
-func (m *Binance) FetchPositions() (interface{}, error) {
+// FetchPositions 拉取 U 本位持仓，开启币本位时一并合并
+func (m *Binance) FetchPositions() ([]model.Position, error) {
+	if err := m.Limiter.Wait(context.Background(), PriorityPoll, binancePositionWeight); err != nil {
+		return nil, err
+	}
 	positions, err := m.Exchange.FetchPositions()
 	if err != nil {
 		log.Printf("⚠️ Fetch positions error: %v", err)
 		return nil, err
 	}
-	return positions, nil
+	result := make([]model.Position, 0, len(positions))
+	for i := range positions {
+		position := fromCCXTPosition(positions[i])
+		// 按杠杆分层自算维持保证金、保证金率与强平价，ccxt 的 marginRatio 口径不透明
+		symbol, _ := positions[i].Info["symbol"].(string)
+		wallet, _ := positions[i].Info["isolatedWallet"].(string)
+		m.Brackets.apply(symbol, &position, parseFloat(wallet))
+		result = append(result, position)
+	}
+
+	if m.Delivery == nil {
+		return result, nil
+	}
+	if err := m.Limiter.Wait(context.Background(), PriorityPoll, binancePositionWeight); err != nil {
+		return nil, err
+	}
+	positions, err = m.Delivery.FetchPositions()
+	if err != nil {
+		log.Printf("⚠️ Fetch delivery positions error: %v", err)
+		return nil, err
+	}
+	for i := range positions {
+		position := fromCCXTPosition(positions[i])
+		m.deliverySymbols.Store(position.Symbol, true)
+		result = append(result, position)
+	}
+	return result, nil
+}
+
+// AddMargin 追加逐仓保证金，币本位合约的金额单位为标的币，双向持仓时带上 positionSide
+func (m *Binance) AddMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, amount, false)
+}
+
+// ReduceMargin 减少逐仓保证金
+func (m *Binance) ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, amount, true)
+}
+
+// changeMargin 通过 ccxt 调整逐仓保证金，按交易对选择 U 本位或币本位实例
+func (m *Binance) changeMargin(ps model.Position, amount float64, reduce bool) (*model.MarginResult, error) {
+	symbol := ps.Symbol
+	if err := m.Limiter.Wait(context.Background(), PriorityMargin, binanceMarginWeight); err != nil {
+		return marginFailed(symbol, err)
+	}
+	params := map[string]interface{}{}
+	if ps.Hedged {
+		params["positionSide"] = strings.ToUpper(ps.Side)
+	}
+	exchange := &m.Exchange
+	if _, ok := m.deliverySymbols.Load(symbol); ok && m.Delivery != nil {
+		exchange = m.Delivery
+	}
+	if reduce {
+		return marginResultFromCCXT(m.Name, "Binance", symbol, <-exchange.ReduceMargin(symbol, amount, params))
+	}
+	return marginResultFromCCXT(m.Name, "Binance", symbol, <-exchange.AddMargin(symbol, amount, params))
 }
 
-func (m *Binance) AddMargin(symbol string, amount float64) string {
-	marginChan := m.Exchange.AddMargin(symbol, amount)
-	result := <-marginChan
-	if resultData, ok := result.(map[string]interface{}); ok {
-		if status, ok := resultData["status"].(string); ok && status == "ok" {
-			msg := fmt.Sprintf("✅ Margin added: %s +%.2f USDT", symbol, amount)
-			log.Println(msg)
-			return msg
-		}
+// FetchBalance 稳定币查询 U 本位账户，其他币种在开启币本位时查询币本位账户；
+// U 本位多资产模式下也可能持有 BTC 等资产，但不能用于币本位合约保证金，不能按是否存在来选择账户
+func (m *Binance) FetchBalance(asset string) (*model.Balance, error) {
+	if err := m.Limiter.Wait(context.Background(), PriorityMargin, binanceBalanceWeight); err != nil {
+		return nil, err
+	}
+	exchange := &m.Exchange
+	if !isStableAsset(asset) && m.Delivery != nil {
+		exchange = m.Delivery
+	}
+	balances, err := exchange.FetchBalance()
+	if err != nil {
+		log.Printf("⚠️ Fetch balance error: %v", err)
+		return nil, err
 	}
+	return &model.Balance{
+		Asset:     asset,
+		Total:     floatValue(balances.Total[asset]),
+		Available: floatValue(balances.Free[asset]),
+	}, nil
+}
 
-	msg := fmt.Sprintf("❌ Margin add failed: %s +%.2f USDT", symbol, amount)
-	println(msg)
-	return msg
+func (m *Binance) Capabilities() Capabilities {
+	caps := newCapabilities(CapAddMargin, CapReduceMargin, CapHedgeMode, CapTransfer,
+		CapSubAccountTransfer, CapAccountRisk, CapReducePosition, CapStream, CapCandles)
+	if m.SapiURL == "" {
+		caps = caps.disable(CapTransfer, "not available on testnet / demo trading")
+		caps = caps.disable(CapSubAccountTransfer, "not available on testnet / demo trading")
+	}
+	return caps
 }
 
 func (m *Binance) GetName() string {
-	return "Binance"
+	return m.Name
+}
+
+// fromCCXTPosition 将 ccxt 统一持仓转换为 model.Position
+func fromCCXTPosition(ps ccxt.Position) model.Position {
+	// 双向持仓模式下 positionSide 为 LONG / SHORT，单向为 BOTH
+	positionSide, _ := ps.Info["positionSide"].(string)
+	return model.Position{
+		Symbol:            stringValue(ps.Symbol),
+		Side:              stringValue(ps.Side),
+		Size:              floatValue(ps.Contracts),
+		EntryPrice:        floatValue(ps.EntryPrice),
+		MarkPrice:         floatValue(ps.MarkPrice),
+		LiquidationPrice:  floatValue(ps.LiquidationPrice),
+		MarginMode:        stringValue(ps.MarginMode),
+		MarginAsset:       settleAsset(stringValue(ps.Symbol)),
+		InitialMargin:     floatValue(ps.InitialMargin),
+		MaintenanceMargin: floatValue(ps.MaintenanceMargin),
+		MarginRatio:       floatValue(ps.MarginRatio),
+		Leverage:          floatValue(ps.Leverage),
+		UnrealizedPnl:     floatValue(ps.UnrealizedPnl),
+		Hedged:            (ps.Hedged != nil && *ps.Hedged) || (positionSide != "" && positionSide != "BOTH"),
+	}
+}
+
+// isStableAsset U 本位合约使用的稳定币保证金
+func isStableAsset(asset string) bool {
+	switch asset {
+	case "USDT", "USDC", "FDUSD", "BUSD":
+		return true
+	}
+	return false
+}
+
+// settleAsset 从 ccxt 统一交易对中取结算币种，如 BTC/USDT:USDT -> USDT，BTC/USD:BTC-250926 -> BTC
+func settleAsset(symbol string) string {
+	_, settle, ok := strings.Cut(symbol, ":")
+	if !ok {
+		return ""
+	}
+	settle, _, _ = strings.Cut(settle, "-")
+	return settle
+}
+
+func stringValue(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func floatValue(p *float64) float64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// observeBinanceWeight 读取 X-MBX-USED-WEIGHT-1M 校正已用权重，429 / 418 时按 Retry-After 暂停请求
+func observeBinanceWeight(limiter *RateLimiter, resp *http.Response) {
+	if used, err := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil {
+		limiter.Update(used)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		limiter.Pause(time.Now().Add(retryAfter(resp, time.Minute)))
+	case http.StatusTeapot:
+		limiter.Pause(time.Now().Add(retryAfter(resp, 2*time.Minute)))
+	}
 }
@@ -0,0 +1,104 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	ccxt "github.com/ccxt/ccxt/go/v4"
+	"log"
+	"margin_monitor/model"
+	"net/http"
+	"strings"
+)
+
+// FetchAccountRisk 查询 U 本位账户的全仓保证金率，币本位账户暂不支持
+func (m *Binance) FetchAccountRisk(asset string) (*model.AccountRisk, error) {
+	if !isStableAsset(asset) {
+		return nil, fmt.Errorf("[%s] account risk for %s (COIN-M) not supported", m.Name, asset)
+	}
+	var account struct {
+		TotalMarginBalance string `json:"totalMarginBalance"`
+		TotalInitialMargin string `json:"totalInitialMargin"`
+		TotalMaintMargin   string `json:"totalMaintMargin"`
+		AvailableBalance   string `json:"availableBalance"`
+	}
+	ctx := withPriority(context.Background(), PriorityPoll)
+	if err := m.signedRequestContext(ctx, http.MethodGet, m.FapiURL, "/fapi/v2/account", nil, &account); err != nil {
+		log.Printf("⚠️ [%s] Fetch account error: %v", m.Name, err)
+		return nil, err
+	}
+	risk := &model.AccountRisk{
+		Asset:             asset,
+		Equity:            parseFloat(account.TotalMarginBalance),
+		InitialMargin:     parseFloat(account.TotalInitialMargin),
+		MaintenanceMargin: parseFloat(account.TotalMaintMargin),
+		Available:         parseFloat(account.AvailableBalance),
+	}
+	if risk.Equity > 0 {
+		risk.MarginRatio = risk.MaintenanceMargin / risk.Equity
+	}
+	return risk, nil
+}
+
+// fetchBrackets 查询全部 U 本位交易对的杠杆分层，一次请求缓存所有交易对
+func (m *Binance) fetchBrackets(string) (map[string][]model.Bracket, error) {
+	var list []model.BinanceLeverageBracket
+	ctx := withPriority(context.Background(), PriorityPoll)
+	if err := m.signedRequestContext(ctx, http.MethodGet, m.FapiURL, "/fapi/v1/leverageBracket", nil, &list); err != nil {
+		return nil, err
+	}
+	result := make(map[string][]model.Bracket, len(list))
+	for _, item := range list {
+		brackets := make([]model.Bracket, 0, len(item.Brackets))
+		for _, b := range item.Brackets {
+			brackets = append(brackets, model.Bracket{
+				Cap:             b.NotionalCap,
+				MaintMarginRate: b.MaintMarginRatio,
+				MaintAmount:     b.Cum,
+				MaxLeverage:     b.InitialLeverage,
+			})
+		}
+		result[item.Symbol] = brackets
+	}
+	return result, nil
+}
+
+// ReducePosition 以市价单减仓 size 张，数量按交易对步长向下取整；双向持仓时指定 positionSide，单向持仓使用 reduceOnly
+func (m *Binance) ReducePosition(ps model.Position, size float64) (*model.MarginResult, error) {
+	step, err := m.qtyStep(ps.Symbol)
+	if err != nil {
+		log.Printf("❌ [%s] reduce %s error: %v", m.Name, ps.Symbol, err)
+		return marginFailed(ps.Symbol, err)
+	}
+	if size, _ = floorToStep(size, step); size <= 0 {
+		return marginFailed(ps.Symbol, fmt.Errorf("%w %s", ErrBelowQtyStep, step))
+	}
+	if err := m.Limiter.Wait(context.Background(), PriorityMargin, binanceMarginWeight); err != nil {
+		return marginFailed(ps.Symbol, err)
+	}
+	side := "sell"
+	if ps.Side == model.SideShort {
+		side = "buy"
+	}
+	params := map[string]interface{}{}
+	if ps.Hedged {
+		params["positionSide"] = strings.ToUpper(ps.Side)
+	} else {
+		params["reduceOnly"] = true
+	}
+	exchange := &m.Exchange
+	if _, ok := m.deliverySymbols.Load(ps.Symbol); ok && m.Delivery != nil {
+		exchange = m.Delivery
+	}
+	order, err := exchange.CreateOrder(ps.Symbol, "market", side, size, ccxt.WithCreateOrderParams(params))
+	if err != nil {
+		log.Printf("❌ [%s] reduce %s %.6f error: %v", m.Name, ps.Symbol, size, err)
+		return marginFailed(ps.Symbol, err)
+	}
+	log.Printf("✂️ [%s] reduced %s %s by %.6f, order %s", m.Name, ps.Symbol, ps.Side, size, stringValue(order.Id))
+	return &model.MarginResult{
+		Status: model.MarginStatusSuccess,
+		Symbol: ps.Symbol,
+		Amount: size,
+		TxID:   stringValue(order.Id),
+	}, nil
+}
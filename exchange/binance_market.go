@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"margin_monitor/model"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FetchCandles 查询 U 本位合约 K 线（公开接口），币本位合约不支持
+func (m *Binance) FetchCandles(symbol string, interval string, limit int) ([]model.Candle, error) {
+	id, ok := binanceMarketID(symbol)
+	if !ok {
+		return nil, fmt.Errorf("[%s] candles for %s not supported", m.Name, symbol)
+	}
+	query := url.Values{"symbol": {id}, "interval": {interval}, "limit": {strconv.Itoa(limit)}}
+	var rows [][]interface{}
+	if err := m.publicGet(withPriority(context.Background(), PriorityPoll), "/fapi/v1/klines", query, &rows); err != nil {
+		return nil, err
+	}
+	// 每根 K 线为 [开盘时间, 开, 高, 低, 收, ...]，价格为字符串
+	candles := make([]model.Candle, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		openTime, _ := row[0].(float64)
+		candles = append(candles, model.Candle{
+			Time:  int64(openTime),
+			Open:  parseFloat(fmt.Sprint(row[1])),
+			High:  parseFloat(fmt.Sprint(row[2])),
+			Low:   parseFloat(fmt.Sprint(row[3])),
+			Close: parseFloat(fmt.Sprint(row[4])),
+		})
+	}
+	return candles, nil
+}
+
+// qtyStep 返回市价单数量步长，币本位合约按张下单，步长为 1
+func (m *Binance) qtyStep(symbol string) (string, error) {
+	if _, ok := m.deliverySymbols.Load(symbol); ok && m.Delivery != nil {
+		return "1", nil
+	}
+	if step, ok := m.qtySteps.Load(symbol); ok {
+		return step.(string), nil
+	}
+	id, ok := binanceMarketID(symbol)
+	if !ok {
+		return "", fmt.Errorf("[%s] exchange info for %s not supported", m.Name, symbol)
+	}
+	var info model.BinanceExchangeInfo
+	if err := m.publicGet(withPriority(context.Background(), PriorityMargin), "/fapi/v1/exchangeInfo", url.Values{"symbol": {id}}, &info); err != nil {
+		return "", err
+	}
+	for _, item := range info.Symbols {
+		if item.Symbol != id {
+			continue
+		}
+		// 市价单使用 MARKET_LOT_SIZE，缺少时退回 LOT_SIZE
+		var step string
+		for _, filter := range item.Filters {
+			if filter.FilterType == "MARKET_LOT_SIZE" || (filter.FilterType == "LOT_SIZE" && step == "") {
+				step = filter.StepSize
+			}
+		}
+		if step == "" {
+			break
+		}
+		m.qtySteps.Store(symbol, step)
+		return step, nil
+	}
+	return "", fmt.Errorf("[%s] lot size for %s not found", m.Name, symbol)
+}
+
+// publicGet 调用 U 本位公开接口
+func (m *Binance) publicGet(ctx context.Context, path string, query url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.FapiURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// binanceMarketID 将 ccxt U 本位永续交易对转换为 Binance 交易对，如 BTC/USDT:USDT -> BTCUSDT
+func binanceMarketID(symbol string) (string, bool) {
+	pair, settle, ok := strings.Cut(symbol, ":")
+	if !ok {
+		return strings.ReplaceAll(symbol, "/", ""), true
+	}
+	if !isStableAsset(settle) {
+		return "", false
+	}
+	return strings.ReplaceAll(pair, "/", ""), true
+}
@@ -0,0 +1,75 @@
+package exchange
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const binanceSapiURL = "https://api.binance.com"
+
+// signedRequest 发送 Binance SIGNED 接口请求，参数放在 query 中，结果解析到 out
+// 钱包与划转请求都发生在追加保证金流程中，优先于轮询
+func (m *Binance) signedRequest(method string, baseURL string, path string, params url.Values, out interface{}) error {
+	return m.signedRequestContext(withPriority(context.Background(), PriorityMargin), method, baseURL, path, params, out)
+}
+
+// signedRequestContext 同 signedRequest，由 ctx 指定请求优先级
+func (m *Binance) signedRequestContext(ctx context.Context, method string, baseURL string, path string, params url.Values, out interface{}) error {
+	if baseURL == "" {
+		return fmt.Errorf("%s is not available on testnet / demo trading", path)
+	}
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	params.Set("recvWindow", "5000")
+	query := params.Encode()
+	mac := hmac.New(sha256.New, []byte(m.Secret))
+	mac.Write([]byte(query))
+	query += "&signature=" + hex.EncodeToString(mac.Sum(nil))
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path+"?"+query, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("X-MBX-APIKEY", m.Key)
+
+	// sapi 与 fapi 的权重分别计数，429 也只暂停对应的接口
+	client := m.Client
+	if baseURL == m.SapiURL && m.SapiClient != nil {
+		client = m.SapiClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if err := json.Unmarshal(body, &apiErr); err != nil {
+			return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, body)
+		}
+		return &APIError{Exchange: "Binance", Code: strconv.Itoa(apiErr.Code), Msg: apiErr.Msg}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
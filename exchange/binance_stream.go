@@ -0,0 +1,179 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"margin_monitor/model"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	binanceKeepAliveInterval = 30 * time.Minute
+	binanceReconnectDelay    = 5 * time.Second
+	// 服务端每 3 分钟发送 ping，超过该时间没有 ping 或消息视为连接已失效
+	binanceReadTimeout = 5 * time.Minute
+)
+
+// binanceUserEvent USDⓈ-M 用户数据流事件，只解析需要的字段
+// encoding/json 大小写不敏感，E 需要单独声明，否则会匹配到 e
+type binanceUserEvent struct {
+	Event     string `json:"e"`
+	EventTime int64  `json:"E"`
+	// ACCOUNT_UPDATE
+	Account struct {
+		Reason    string `json:"m"`
+		Positions []struct {
+			Symbol string `json:"s"`
+			Amount string `json:"pa"`
+		} `json:"P"`
+	} `json:"a"`
+	// MARGIN_CALL
+	CrossWallet string `json:"cw"`
+	MarginCalls []struct {
+		Symbol            string `json:"s"`
+		PositionSide      string `json:"ps"`
+		MarginType        string `json:"mt"`
+		MarkPrice         string `json:"mp"`
+		MaintenanceMargin string `json:"mm"`
+	} `json:"p"`
+}
+
+// Subscribe 订阅 USDⓈ-M 用户数据流，ACCOUNT_UPDATE / MARGIN_CALL 到达时通知重新检查持仓
+func (m *Binance) Subscribe(ctx context.Context, events chan<- model.PositionEvent) error {
+	for {
+		err := m.stream(ctx, events)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("⚠️ [%s] user data stream disconnected: %v, reconnecting in %s", m.Name, err, binanceReconnectDelay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(binanceReconnectDelay):
+		}
+	}
+}
+
+// stream 建立一次用户数据流连接，连接断开或 listenKey 过期时返回
+func (m *Binance) stream(ctx context.Context, events chan<- model.PositionEvent) error {
+	listenKey, err := m.listenKey(http.MethodPost)
+	if err != nil {
+		return err
+	}
+	conn, _, err := m.Dialer.DialContext(ctx, m.StreamURL+listenKey, nil)
+	if err != nil {
+		return fmt.Errorf("dial user data stream: %w", err)
+	}
+	defer conn.Close()
+	log.Printf("[%s] user data stream connected", m.Name)
+
+	// listenKey 60 分钟过期，定时续期；ctx 结束时关闭连接使读取返回
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(binanceKeepAliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				if _, err := m.listenKey(http.MethodPut); err != nil {
+					log.Printf("⚠️ [%s] listenKey keepalive error: %v", m.Name, err)
+				}
+			}
+		}
+	}()
+
+	// 半开连接不会返回错误，靠读超时发现并重连；收到 ping 或消息时延长
+	extend := func() error {
+		return conn.SetReadDeadline(time.Now().Add(binanceReadTimeout))
+	}
+	conn.SetPingHandler(func(data string) error {
+		if err := extend(); err != nil {
+			return err
+		}
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+	for {
+		if err := extend(); err != nil {
+			return err
+		}
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var event binanceUserEvent
+		if err := json.Unmarshal(message, &event); err != nil {
+			log.Printf("⚠️ [%s] invalid user data event: %v", m.Name, err)
+			continue
+		}
+
+		switch event.Event {
+		case "ACCOUNT_UPDATE":
+			if len(event.Account.Positions) == 0 {
+				continue
+			}
+			log.Printf("[%s] ACCOUNT_UPDATE (%s): %d positions changed", m.Name, event.Account.Reason, len(event.Account.Positions))
+		case "MARGIN_CALL":
+			for _, call := range event.MarginCalls {
+				log.Printf("🚨 [%s] MARGIN_CALL: Symbol=%s, Side=%s, MarginType=%s, MarkPrice=%s, MaintenanceMargin=%s", m.Name,
+					call.Symbol, call.PositionSide, call.MarginType, call.MarkPrice, call.MaintenanceMargin)
+			}
+		case "listenKeyExpired":
+			return fmt.Errorf("listenKey expired")
+		default:
+			continue
+		}
+
+		select {
+		case events <- model.PositionEvent{Reason: event.Event}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// listenKey POST 创建 / PUT 续期 USDⓈ-M listenKey
+func (m *Binance) listenKey(method string) (string, error) {
+	req, err := http.NewRequest(method, m.FapiURL+"/fapi/v1/listenKey", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("X-MBX-APIKEY", m.Key)
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("listenKey request failed, status code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %v", err)
+	}
+	return result.ListenKey, nil
+}
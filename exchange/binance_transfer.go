@@ -0,0 +1,121 @@
+package exchange
+
+import (
+	"fmt"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// binanceWalletAsset 现货 / 资金账户资产
+type binanceWalletAsset struct {
+	Asset  string `json:"asset"`
+	Free   string `json:"free"`
+	Locked string `json:"locked"`
+}
+
+// FundingWallets 依次从现货与资金账户划转
+func (m *Binance) FundingWallets() []string {
+	return []string{WalletSpot, WalletFunding}
+}
+
+// FetchWalletBalance 查询现货（spot）或资金（funding）账户余额
+func (m *Binance) FetchWalletBalance(wallet string, asset string) (*model.Balance, error) {
+	var path string
+	switch wallet {
+	case WalletSpot:
+		path = "/sapi/v3/asset/getUserAsset"
+	case WalletFunding:
+		path = "/sapi/v1/asset/get-funding-asset"
+	default:
+		return nil, fmt.Errorf("[%s] unsupported wallet: %s", m.Name, wallet)
+	}
+
+	var assets []binanceWalletAsset
+	if err := m.signedRequest(http.MethodPost, m.SapiURL, path, url.Values{"asset": {asset}}, &assets); err != nil {
+		log.Printf("⚠️ [%s] Fetch %s balance error: %v", m.Name, wallet, err)
+		return nil, err
+	}
+	balance := &model.Balance{Asset: asset}
+	for _, a := range assets {
+		if a.Asset == asset {
+			balance.Available = parseFloat(a.Free)
+			balance.Total = balance.Available + parseFloat(a.Locked)
+		}
+	}
+	return balance, nil
+}
+
+// TransferToFutures 通过万向划转把现货 / 资金账户的资产转入合约账户，币本位资产转入 COIN-M
+func (m *Binance) TransferToFutures(wallet string, asset string, amount float64) (*model.TransferResult, error) {
+	target := "UMFUTURE"
+	if m.Delivery != nil && !isStableAsset(asset) {
+		target = "CMFUTURE"
+	}
+	var source string
+	switch wallet {
+	case WalletSpot:
+		source = "MAIN"
+	case WalletFunding:
+		source = "FUNDING"
+	default:
+		return nil, fmt.Errorf("[%s] unsupported wallet: %s", m.Name, wallet)
+	}
+
+	params := url.Values{
+		"type":   {source + "_" + target},
+		"asset":  {asset},
+		"amount": {strconv.FormatFloat(amount, 'f', -1, 64)},
+	}
+	var result struct {
+		TranId int64 `json:"tranId"`
+	}
+	if err := m.signedRequest(http.MethodPost, m.SapiURL, "/sapi/v1/asset/transfer", params, &result); err != nil {
+		log.Printf("❌ [%s] transfer %s %.4f %s -> %s error: %v", m.Name, asset, amount, source, target, err)
+		return nil, err
+	}
+	log.Printf("💸 [%s] transferred %.4f %s %s -> %s, tranId %d", m.Name, amount, asset, source, target, result.TranId)
+	return &model.TransferResult{
+		TxID:   strconv.FormatInt(result.TranId, 10),
+		Asset:  asset,
+		Amount: amount,
+		From:   wallet,
+		To:     WalletFutures,
+	}, nil
+}
+
+// FundSubAccount 主账户现货钱包通过万向划转向子账户合约钱包转入资产
+func (m *Binance) FundSubAccount(sub config.SubAccount, asset string, amount float64) (*model.TransferResult, error) {
+	if sub.Email == "" {
+		return nil, fmt.Errorf("[%s] sub-account %s email not set", m.Name, sub.Name)
+	}
+	target := "USDT_FUTURE"
+	if !isStableAsset(asset) {
+		target = "COIN_FUTURE"
+	}
+	params := url.Values{
+		"toEmail":         {sub.Email},
+		"fromAccountType": {"SPOT"},
+		"toAccountType":   {target},
+		"asset":           {asset},
+		"amount":          {strconv.FormatFloat(amount, 'f', -1, 64)},
+	}
+	var result struct {
+		TranId int64 `json:"tranId"`
+	}
+	if err := m.signedRequest(http.MethodPost, m.SapiURL, "/sapi/v1/sub-account/universalTransfer", params, &result); err != nil {
+		log.Printf("❌ [%s] transfer %.4f %s to sub-account %s error: %v", m.Name, amount, asset, sub.Name, err)
+		return nil, err
+	}
+	log.Printf("💸 [%s] transferred %.4f %s to sub-account %s %s, tranId %d", m.Name, amount, asset, sub.Name, target, result.TranId)
+	return &model.TransferResult{
+		TxID:   strconv.FormatInt(result.TranId, 10),
+		Asset:  asset,
+		Amount: amount,
+		From:   WalletSpot,
+		To:     sub.Name,
+	}, nil
+}
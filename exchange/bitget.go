@@ -0,0 +1,230 @@
+package exchange
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	bitgetBaseURL     = "https://api.bitget.com"
+	bitgetProductType = "USDT-FUTURES"
+	bitgetMarginCoin  = "USDT"
+)
+
+type Bitget struct {
+	Name       string
+	BaseURL    string
+	Key        string
+	Secret     string
+	Passphrase string
+	Client     *http.Client
+}
+
+func NewBitget(conf config.Exchange, proxy string) Exchange {
+	return &Bitget{
+		Name:       accountName("Bitget", conf),
+		BaseURL:    bitgetBaseURL,
+		Key:        conf.ExchangeKey,
+		Secret:     conf.ExchangeSecret,
+		Passphrase: conf.Passphrase,
+		Client:     newHTTPClient(proxy),
+	}
+}
+
+type bitgetResponse struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (m *Bitget) FetchPositions() ([]model.Position, error) {
+	var positions []model.BitgetPosition
+	query := url.Values{"productType": {bitgetProductType}, "marginCoin": {bitgetMarginCoin}}
+	if err := m.request(http.MethodGet, "/api/v2/mix/position/all-position", query, nil, &positions); err != nil {
+		log.Printf("[%s] Fetch Positions Error: %v", m.Name, err.Error())
+		return nil, err
+	}
+	list := make([]model.Position, 0, len(positions))
+	for i := range positions {
+		list = append(list, fromBitgetPosition(positions[i]))
+	}
+	return list, nil
+}
+
+// AddMargin 追加逐仓保证金
+func (m *Bitget) AddMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, amount)
+}
+
+// ReduceMargin 减少逐仓保证金
+func (m *Bitget) ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, -amount)
+}
+
+// changeMargin 调整逐仓保证金，amount 为正追加、为负减少，双向持仓时需指定 holdSide
+func (m *Bitget) changeMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	symbol := ps.Symbol
+	body := map[string]interface{}{
+		"symbol":      symbol,
+		"productType": bitgetProductType,
+		"marginCoin":  bitgetMarginCoin,
+		"amount":      strconv.FormatFloat(amount, 'f', -1, 64),
+	}
+	if ps.Hedged {
+		body["holdSide"] = ps.Side
+	}
+	if err := m.request(http.MethodPost, "/api/v2/mix/account/set-margin", nil, body, nil); err != nil {
+		log.Printf("[%s] Change Margin Error: %v", m.Name, err.Error())
+		return marginFailed(symbol, err)
+	}
+	return &model.MarginResult{
+		Status: model.MarginStatusSuccess,
+		Symbol: symbol,
+		Amount: math.Abs(amount),
+	}, nil
+}
+
+func (m *Bitget) FetchBalance(asset string) (*model.Balance, error) {
+	var accounts []model.BitgetAccount
+	query := url.Values{"productType": {bitgetProductType}}
+	if err := m.request(http.MethodGet, "/api/v2/mix/account/accounts", query, nil, &accounts); err != nil {
+		log.Printf("[%s] Fetch Balance Error: %v", m.Name, err.Error())
+		return nil, err
+	}
+	balance := &model.Balance{Asset: asset}
+	for _, account := range accounts {
+		if account.MarginCoin == asset {
+			balance.Total = parseFloat(account.AccountEquity)
+			balance.Available = parseFloat(account.Available)
+		}
+	}
+	return balance, nil
+}
+
+// FetchAccountRisk 查询保证金币种账户的全仓风险率
+func (m *Bitget) FetchAccountRisk(asset string) (*model.AccountRisk, error) {
+	var accounts []model.BitgetAccount
+	query := url.Values{"productType": {bitgetProductType}}
+	if err := m.request(http.MethodGet, "/api/v2/mix/account/accounts", query, nil, &accounts); err != nil {
+		log.Printf("[%s] Fetch Account Error: %v", m.Name, err)
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.MarginCoin != asset {
+			continue
+		}
+		risk := &model.AccountRisk{
+			Asset:       asset,
+			Equity:      parseFloat(account.AccountEquity),
+			Available:   parseFloat(account.Available),
+			MarginRatio: parseFloat(account.CrossedRiskRate),
+		}
+		risk.MaintenanceMargin = risk.MarginRatio * risk.Equity
+		return risk, nil
+	}
+	return nil, fmt.Errorf("[%s] %s account not found", m.Name, asset)
+}
+
+func (m *Bitget) Capabilities() Capabilities {
+	return newCapabilities(CapAddMargin, CapReduceMargin, CapHedgeMode, CapAccountRisk)
+}
+
+func (m *Bitget) GetName() string {
+	return m.Name
+}
+
+// request 发送签名请求并解析 data 字段
+func (m *Bitget) request(method string, path string, query url.Values, body interface{}, out interface{}) error {
+	requestPath := path
+	if len(query) > 0 {
+		requestPath += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal error: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, m.BaseURL+requestPath, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("locale", "en-US")
+	req.Header.Set("ACCESS-KEY", m.Key)
+	req.Header.Set("ACCESS-SIGN", m.sign(timestamp+method+requestPath+string(payload)))
+	req.Header.Set("ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("ACCESS-PASSPHRASE", m.Passphrase)
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var result bitgetResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse response (status %d): %v", resp.StatusCode, err)
+	}
+	if result.Code != "00000" {
+		return &APIError{Exchange: "Bitget", Code: result.Code, Msg: result.Msg}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+func (m *Bitget) sign(message string) string {
+	mac := hmac.New(sha256.New, []byte(m.Secret))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// fromBitgetPosition 将 Bitget 原始持仓转换为 model.Position
+func fromBitgetPosition(ps model.BitgetPosition) model.Position {
+	position := model.Position{
+		Symbol:           ps.Symbol,
+		Side:             ps.HoldSide,
+		Size:             parseFloat(ps.Total),
+		EntryPrice:       parseFloat(ps.OpenPriceAvg),
+		MarkPrice:        parseFloat(ps.MarkPrice),
+		LiquidationPrice: parseFloat(ps.LiquidationPrice),
+		MarginMode:       model.MarginModeIsolated,
+		MarginAsset:      ps.MarginCoin,
+		InitialMargin:    parseFloat(ps.MarginSize),
+		MarginRatio:      parseFloat(ps.MarginRatio),
+		Leverage:         parseFloat(ps.Leverage),
+		UnrealizedPnl:    parseFloat(ps.UnrealizedPL),
+		Hedged:           ps.PosMode == "hedge_mode",
+	}
+	// Bitget 全仓为 crossed
+	if ps.MarginMode == "crossed" {
+		position.MarginMode = model.MarginModeCross
+	}
+	position.MaintenanceMargin = parseFloat(ps.KeepMarginRate) * position.MarkPrice * position.Size
+	return position
+}
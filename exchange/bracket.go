@@ -0,0 +1,179 @@
+package exchange
+
+import (
+	"log"
+	"margin_monitor/model"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// bracketTTL 分层缓存时间，交易所调整分层不频繁
+	bracketTTL = time.Hour
+	// bracketRetry 拉取分层失败后的重试间隔，期间使用交易所返回值
+	bracketRetry = time.Minute
+	// riskTolerance 自算值与交易所返回值的相对偏差超过该比例时告警
+	riskTolerance = 0.05
+	// riskWarnInterval 同一持仓偏差告警的最小间隔
+	riskWarnInterval = 10 * time.Minute
+)
+
+// bracketCache 按交易对缓存杠杆分层，load 可一次返回多个交易对（Binance）或只返回请求的交易对（ByBit）
+type bracketCache struct {
+	name string
+	load func(symbol string) (map[string][]model.Bracket, error)
+
+	mu       sync.Mutex
+	brackets map[string][]model.Bracket
+	expires  map[string]time.Time
+	loading  map[string]chan struct{} // 正在拉取的交易对，拉取结束时关闭
+	warned   sync.Map                 // symbol/side/字段 -> 上次偏差告警时间
+}
+
+func newBracketCache(name string, load func(symbol string) (map[string][]model.Bracket, error)) *bracketCache {
+	return &bracketCache{
+		name:     name,
+		load:     load,
+		brackets: make(map[string][]model.Bracket),
+		expires:  make(map[string]time.Time),
+		loading:  make(map[string]chan struct{}),
+	}
+}
+
+// get 返回交易对的分层（按 Cap 升序），拉取失败或交易对不存在时返回 nil
+// 拉取在锁外进行，同一交易对同时只有一个请求，其余调用等待该请求结束后读取缓存
+func (c *bracketCache) get(symbol string) []model.Bracket {
+	for {
+		c.mu.Lock()
+		if time.Now().Before(c.expires[symbol]) {
+			brackets := c.brackets[symbol]
+			c.mu.Unlock()
+			return brackets
+		}
+		if wait, ok := c.loading[symbol]; ok {
+			c.mu.Unlock()
+			<-wait
+			continue
+		}
+		done := make(chan struct{})
+		c.loading[symbol] = done
+		c.mu.Unlock()
+
+		loaded, err := c.load(symbol)
+		return c.store(symbol, loaded, err, done)
+	}
+}
+
+// store 写入拉取结果并唤醒等待同一交易对的调用
+func (c *bracketCache) store(symbol string, loaded map[string][]model.Bracket, err error, done chan struct{}) []model.Bracket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(done)
+	delete(c.loading, symbol)
+	now := time.Now()
+	if err != nil {
+		log.Printf("⚠️ [%s] fetch leverage brackets error, using exchange reported risk: %v", c.name, err)
+		c.expires[symbol] = now.Add(bracketRetry)
+		return c.brackets[symbol]
+	}
+	for s, brackets := range loaded {
+		sort.Slice(brackets, func(i, j int) bool { return brackets[i].Cap < brackets[j].Cap })
+		c.brackets[s] = brackets
+		c.expires[s] = now.Add(bracketTTL)
+	}
+	// 未返回的交易对同样缓存，避免每次轮询都请求
+	if _, ok := loaded[symbol]; !ok {
+		c.expires[symbol] = now.Add(bracketTTL)
+	}
+	return c.brackets[symbol]
+}
+
+// apply 按分层计算 U 本位持仓的维持保证金，逐仓持仓同时计算保证金率与强平价，
+// 与交易所返回值比对后以自算值为准；wallet 为逐仓保证金余额（不含未实现盈亏）
+func (c *bracketCache) apply(symbol string, ps *model.Position, wallet float64) {
+	brackets := c.get(symbol)
+	if len(brackets) == 0 || ps.Size == 0 || ps.MarkPrice == 0 {
+		return
+	}
+	reported := *ps
+	ps.MaintenanceMargin = maintenanceMargin(brackets, ps.Size*ps.MarkPrice)
+	if ps.MarginMode == model.MarginModeIsolated {
+		if balance := wallet + ps.UnrealizedPnl; balance > 0 {
+			ps.MarginRatio = ps.MaintenanceMargin / balance
+		}
+		ps.LiquidationPrice = liquidationPrice(brackets, *ps, wallet)
+	}
+	c.crossCheck(reported, *ps)
+}
+
+// crossCheck 自算值与交易所返回值偏差过大时告警，同一持仓限频
+func (c *bracketCache) crossCheck(reported model.Position, computed model.Position) {
+	type field struct {
+		name               string
+		reported, computed float64
+	}
+	// 保证金率各交易所口径不同（ByBit 为本地计算），只比对交易所直接返回的字段
+	fields := []field{{"maintenance margin", reported.MaintenanceMargin, computed.MaintenanceMargin}}
+	if computed.MarginMode == model.MarginModeIsolated {
+		fields = append(fields, field{"liquidation price", reported.LiquidationPrice, computed.LiquidationPrice})
+	}
+	for _, f := range fields {
+		if f.reported == 0 || math.Abs(f.computed-f.reported)/math.Abs(f.reported) <= riskTolerance {
+			continue
+		}
+		// 按字段限频，维持保证金的告警不会压住强平价的告警
+		key := computed.Symbol + "/" + computed.Side + "/" + f.name
+		if last, ok := c.warned.Load(key); ok && time.Since(last.(time.Time)) < riskWarnInterval {
+			continue
+		}
+		c.warned.Store(key, time.Now())
+		log.Printf("⚠️ [%s] %s %s: computed %s %.6f differs from exchange %.6f",
+			c.name, computed.Symbol, computed.Side, f.name, f.computed, f.reported)
+	}
+}
+
+// findBracket 返回名义价值所在的档位，超过最高档时使用最高档
+func findBracket(brackets []model.Bracket, notional float64) model.Bracket {
+	for _, bracket := range brackets {
+		if notional <= bracket.Cap {
+			return bracket
+		}
+	}
+	return brackets[len(brackets)-1]
+}
+
+func maintenanceMargin(brackets []model.Bracket, notional float64) float64 {
+	bracket := findBracket(brackets, notional)
+	return math.Max(notional*bracket.MaintMarginRate-bracket.MaintAmount, 0)
+}
+
+// liquidationPrice 逐仓强平价：保证金余额 + 未实现盈亏 = 维持保证金时的标记价格
+// wallet + s·size·(P - entry) = size·P·mmr - cum，s 多头为 1、空头为 -1；
+// 档位取决于强平时的名义价值，按强平价重新选档迭代几次
+func liquidationPrice(brackets []model.Bracket, ps model.Position, wallet float64) float64 {
+	sign := 1.0
+	if ps.Side == model.SideShort {
+		sign = -1
+	}
+	bracket := findBracket(brackets, ps.Size*ps.MarkPrice)
+	var price float64
+	for i := 0; i < 3; i++ {
+		denominator := ps.Size * (sign - bracket.MaintMarginRate)
+		if denominator == 0 {
+			return 0
+		}
+		price = (sign*ps.Size*ps.EntryPrice - wallet - bracket.MaintAmount) / denominator
+		if price <= 0 {
+			// 多头保证金足以覆盖全部价值，不会强平
+			return 0
+		}
+		next := findBracket(brackets, ps.Size*price)
+		if next == bracket {
+			break
+		}
+		bracket = next
+	}
+	return price
+}
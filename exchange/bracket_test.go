@@ -0,0 +1,142 @@
+package exchange
+
+import (
+	"margin_monitor/model"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// binanceBTCBrackets Binance 文档中 BTCUSDT 的前几档，MaintAmount 满足
+// cum(n) = cum(n-1) + cap(n-1) * (mmr(n) - mmr(n-1))
+var binanceBTCBrackets = []model.Bracket{
+	{Cap: 50000, MaintMarginRate: 0.004, MaintAmount: 0, MaxLeverage: 125},
+	{Cap: 250000, MaintMarginRate: 0.005, MaintAmount: 50, MaxLeverage: 100},
+	{Cap: 1000000, MaintMarginRate: 0.01, MaintAmount: 1300, MaxLeverage: 50},
+	{Cap: 10000000, MaintMarginRate: 0.025, MaintAmount: 16300, MaxLeverage: 20},
+}
+
+func TestMaintenanceMargin(t *testing.T) {
+	tests := []struct {
+		name     string
+		notional float64
+		want     float64
+	}{
+		{"first bracket", 40000, 160},
+		{"bracket boundary", 50000, 200},
+		{"second bracket", 100000, 450},
+		{"third bracket", 500000, 3700},
+		{"fourth bracket", 2000000, 33700},
+		{"above last cap uses last bracket", 20000000, 483700},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maintenanceMargin(binanceBTCBrackets, tt.notional); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("maintenanceMargin(%v) = %v, want %v", tt.notional, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiquidationPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     string
+		size     float64
+		entry    float64
+		wallet   float64
+		want     float64
+		notional float64 // 强平时的名义价值应落在的档位上限
+	}{
+		// Binance 逐仓强平价公式：LP = (WB + cum - side·size·EP) / (size·MMR - side·size)
+		{"long", model.SideLong, 1, 10000, 1000, 9000 / 0.996, 50000},
+		{"short", model.SideShort, 1, 10000, 1000, 11000 / 1.004, 50000},
+		// 标记价格在第二档，强平时名义价值回到第一档，需要按第一档重新计算
+		{"bracket changes at liquidation", model.SideLong, 10, 6000, 12000, 48000 / 9.96, 50000},
+		// 空头强平价上移后名义价值进入第二档
+		{"short moves up a bracket", model.SideShort, 10, 4900, 2000, 51050 / 10.05, 250000},
+		{"long never liquidates", model.SideLong, 1, 10000, 10000, 0, 0},
+		{"long overcollateralized", model.SideLong, 1, 10000, 12000, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := model.Position{Side: tt.side, Size: tt.size, EntryPrice: tt.entry, MarkPrice: tt.entry}
+			got := liquidationPrice(binanceBTCBrackets, ps, tt.wallet)
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Fatalf("liquidationPrice = %v, want %v", got, tt.want)
+			}
+			if got == 0 {
+				return
+			}
+			if bracket := findBracket(binanceBTCBrackets, tt.size*got); bracket.Cap != tt.notional {
+				t.Errorf("liquidation notional %v in bracket %v, want %v", tt.size*got, bracket.Cap, tt.notional)
+			}
+			// 强平价处保证金余额 + 未实现盈亏 = 维持保证金
+			sign := 1.0
+			if tt.side == model.SideShort {
+				sign = -1
+			}
+			equity := tt.wallet + sign*tt.size*(got-tt.entry)
+			if mm := maintenanceMargin(binanceBTCBrackets, tt.size*got); math.Abs(equity-mm) > 1e-6 {
+				t.Errorf("equity %v at liquidation, maintenance margin %v", equity, mm)
+			}
+		})
+	}
+}
+
+func TestCrossCheckThrottlesPerField(t *testing.T) {
+	c := newBracketCache("test", nil)
+	reported := model.Position{Symbol: "BTCUSDT", Side: model.SideLong, MarginMode: model.MarginModeIsolated,
+		MaintenanceMargin: 100, LiquidationPrice: 9000}
+	mmOff := reported
+	mmOff.MaintenanceMargin = 200
+	c.crossCheck(reported, mmOff)
+
+	// 维持保证金已告警限频中，强平价的偏差仍需告警
+	bothOff := mmOff
+	bothOff.LiquidationPrice = 8000
+	c.crossCheck(reported, bothOff)
+	for _, key := range []string{"BTCUSDT/long/maintenance margin", "BTCUSDT/long/liquidation price"} {
+		if _, ok := c.warned.Load(key); !ok {
+			t.Errorf("expected warning recorded for %s", key)
+		}
+	}
+}
+
+func TestBracketCacheLoadsOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var loads atomic.Int32
+	c := newBracketCache("test", func(symbol string) (map[string][]model.Bracket, error) {
+		if symbol == "BTCUSDT" {
+			if loads.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+		}
+		return map[string][]model.Bracket{symbol: append([]model.Bracket(nil), binanceBTCBrackets...)}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := c.get("BTCUSDT"); len(got) != len(binanceBTCBrackets) {
+				t.Errorf("got %d brackets", len(got))
+			}
+		}()
+	}
+	<-started
+
+	// BTCUSDT 拉取中，其他交易对不被阻塞
+	if got := c.get("ETHUSDT"); len(got) != len(binanceBTCBrackets) {
+		t.Errorf("ETHUSDT got %d brackets", len(got))
+	}
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("BTCUSDT loaded %d times, want 1", n)
+	}
+}
@@ -3,63 +3,322 @@ package exchange
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	bybit "github.com/bybit-exchange/bybit.go.api"
+	"github.com/gorilla/websocket"
 	"log"
+	"margin_monitor/config"
 	"margin_monitor/model"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	bybitPrivateStreamURL        = "wss://stream.bybit.com/v5/private"
+	bybitTestnetPrivateStreamURL = "wss://stream-testnet.bybit.com/v5/private"
+	bybitDemoPrivateStreamURL    = "wss://stream-demo.bybit.com/v5/private"
+
+	// IP 限制为每 5 秒 600 次请求，预留部分给保证金操作
+	bybitRequestLimit    = 600
+	bybitRequestReserved = 100
+	bybitLimitWindow     = 5 * time.Second
+	// 接口剩余次数低于该值时暂停轮询直到重置
+	bybitPollMinRemaining = 2
+	// 触发 IP 限制（403 / 429）后交易所封禁约 10 分钟
+	bybitIPBanDuration = 10 * time.Minute
+	// bybitIsolatedMargin 统一账户逐仓保证金模式，其余（REGULAR_MARGIN / PORTFOLIO_MARGIN）为全仓
+	bybitIsolatedMargin = "ISOLATED_MARGIN"
+	// 账户保证金模式缓存时间，切换模式后最多延迟该时间生效
+	bybitMarginModeTTL = time.Minute
 )
 
 type ByBit struct {
-	Exchange *bybit.Client
+	Name           string
+	Exchange       *bybit.Client
+	Limiter        *RateLimiter
+	Key            string
+	Secret         string
+	UID            string // 主账户 UID，向子账户划转时使用
+	StreamURL      string
+	Dialer         *websocket.Dialer
+	AutoAddSymbols map[string]bool // 使用交易所自动追加保证金的交易对
+	Markets        []config.Market
+	Brackets       *bracketCache
+
+	categories  sync.Map // symbol -> category，追加保证金时使用
+	instruments sync.Map // symbol -> bybitInstrument，来自合约信息
+
+	marginModeMu      sync.Mutex
+	marginMode        string // 账户保证金模式
+	marginModeExpires time.Time
 }
 
-func NewByBit(key string, secret string, proxy string) Exchange {
-	client := bybit.NewBybitHttpClient(key, secret, bybit.WithBaseURL(bybit.MAINNET), bybit.WithProxyURL(proxy))
-	return &ByBit{
-		Exchange: client,
+func NewByBit(conf config.Exchange, proxy string) Exchange {
+	name := accountName("ByBit", conf)
+	baseURL, streamURL := bybit.MAINNET, bybitPrivateStreamURL
+	switch {
+	case conf.Testnet:
+		baseURL, streamURL = bybit.TESTNET, bybitTestnetPrivateStreamURL
+		log.Printf("[%s] using testnet", name)
+	case conf.Demo:
+		baseURL, streamURL = bybit.DEMO_ENV, bybitDemoPrivateStreamURL
+		log.Printf("[%s] using demo trading", name)
 	}
+	client := bybit.NewBybitHttpClient(conf.ExchangeKey, conf.ExchangeSecret, bybit.WithBaseURL(baseURL), bybit.WithProxyURL(proxy))
+	limiter := NewRateLimiter(name, bybitRequestLimit, bybitRequestReserved, bybitLimitWindow)
+	client.HTTPClient = limitClient(client.HTTPClient, limiter, observeByBitLimit)
+	autoAddSymbols := make(map[string]bool, len(conf.AutoAddMargin))
+	for _, symbol := range conf.AutoAddMargin {
+		autoAddSymbols[symbol] = true
+	}
+	markets := conf.Markets
+	if len(markets) == 0 {
+		markets = []config.Market{{Category: "linear", SettleCoin: "USDT"}}
+	}
+	m := &ByBit{
+		Name:           name,
+		Exchange:       client,
+		Limiter:        limiter,
+		Key:            conf.ExchangeKey,
+		Secret:         conf.ExchangeSecret,
+		UID:            conf.UID,
+		StreamURL:      streamURL,
+		Dialer:         newWSDialer(proxy),
+		AutoAddSymbols: autoAddSymbols,
+		Markets:        markets,
+	}
+	m.Brackets = newBracketCache(m.Name, m.fetchBrackets)
+	return m
 }
 
-func (m *ByBit) FetchPositions() (interface{}, error) {
-	params := map[string]interface{}{"category": "linear", "settleCoin": "USDT", "limit": 100}
-	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetPositionList(context.Background())
-	if err != nil {
-		log.Println("[ByBit] Fetch Positions Error", err.Error())
-		return nil, err
+// FetchPositions 分页拉取所有配置的 category / settleCoin 持仓并合并
+func (m *ByBit) FetchPositions() ([]model.Position, error) {
+	list := make([]model.Position, 0)
+	for _, market := range m.Markets {
+		positions, err := m.fetchMarketPositions(market)
+		if err != nil {
+			log.Printf("[%s] Fetch Positions Error (%s %s): %v", m.Name, market.Category, market.SettleCoin, err)
+			return nil, err
+		}
+		for i := range positions {
+			m.categories.Store(positions[i].Symbol, market.Category)
+			position, err := m.riskPosition(positions[i])
+			if err != nil {
+				log.Printf("[%s] Fetch Positions Error (%s): %v", m.Name, positions[i].Symbol, err)
+				return nil, err
+			}
+			list = append(list, position)
+		}
 	}
-	if result.RetCode == 0 && result.RetMsg == "OK" {
-		positions, err := mapToStruct[model.PositionList](result.Result)
+	return list, nil
+}
+
+// fetchMarketPositions 按 nextPageCursor 翻页拉取单个 category 的全部持仓
+func (m *ByBit) fetchMarketPositions(market config.Market) ([]model.ByBitPosition, error) {
+	var list []model.ByBitPosition
+	cursor := ""
+	for {
+		params := map[string]interface{}{"category": market.Category, "limit": 200}
+		if market.SettleCoin != "" {
+			params["settleCoin"] = market.SettleCoin
+		}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetPositionList(withPriority(context.Background(), PriorityPoll))
 		if err != nil {
-			log.Println("[ByBit] Fetch Positions Error", err.Error())
+			return nil, err
 		}
-		return positions, err
+		if result.RetCode != 0 {
+			return nil, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+		}
+		positions, err := mapToStruct[model.ByBitPositionList](result.Result)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, positions.List...)
+		if positions.NextPageCursor == "" || len(positions.List) == 0 {
+			return list, nil
+		}
+		cursor = positions.NextPageCursor
+	}
+}
+
+// category 返回交易对所属的 category，未知时默认 linear
+func (m *ByBit) category(symbol string) string {
+	if category, ok := m.categories.Load(symbol); ok {
+		return category.(string)
+	}
+	return "linear"
+}
+
+// AddMargin 按指定金额追加逐仓保证金
+func (m *ByBit) AddMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, amount)
+}
+
+// ReduceMargin 按指定金额减少逐仓保证金
+func (m *ByBit) ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, -amount)
+}
+
+// changeMargin 调整逐仓保证金，margin 为正追加、为负减少
+func (m *ByBit) changeMargin(ps model.Position, margin float64) (*model.MarginResult, error) {
+	symbol := ps.Symbol
+	params := map[string]interface{}{
+		"symbol":      symbol,
+		"category":    m.category(symbol),
+		"margin":      strconv.FormatFloat(margin, 'f', -1, 64),
+		"positionIdx": positionIdx(ps),
 	}
-	return nil, errors.New("[ByBit] Fetch Positions Error")
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).AddOrReduceMargin(withPriority(context.Background(), PriorityMargin))
+	if err != nil {
+		log.Printf("[%s] Change Margin Error: %v", m.Name, err.Error())
+		return marginFailed(symbol, err)
+	}
+	if result.RetCode != 0 {
+		log.Printf("[%s] Change Margin Error: %v %v", m.Name, result.RetCode, result.RetMsg)
+		return marginFailed(symbol, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg})
+	}
+	data, _ := result.Result.(map[string]interface{})
+	return &model.MarginResult{
+		Status:    model.MarginStatusSuccess,
+		Symbol:    symbol,
+		Amount:    math.Abs(margin),
+		NewMargin: mapFloat(data, "positionBalance"),
+	}, nil
+}
+
+// AutoAddEnabled 该交易对是否配置为使用交易所自动追加保证金
+func (m *ByBit) AutoAddEnabled(symbol string) bool {
+	return m.AutoAddSymbols[symbol]
 }
 
-func (m *ByBit) AddMargin(symbol string, amount float64) string {
+// SetAutoAddMargin 开启交易所自动追加保证金
+func (m *ByBit) SetAutoAddMargin(ps model.Position) (*model.MarginResult, error) {
+	symbol := ps.Symbol
 	params := map[string]interface{}{
 		"symbol":        symbol,
-		"category":      "linear",
+		"category":      m.category(symbol),
 		"autoAddMargin": 1,
+		"positionIdx":   positionIdx(ps),
 	}
-	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).SetPositionAutoMargin(context.Background())
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).SetPositionAutoMargin(withPriority(context.Background(), PriorityMargin))
 	if err != nil {
-		log.Println("[ByBit] Add Margin Error", err.Error())
-		return fmt.Sprintf("[ByBit] Add Margin Error: %s", err.Error())
+		log.Printf("[%s] Set Auto Margin Error: %v", m.Name, err.Error())
+		return marginFailed(symbol, err)
 	}
-	if result.RetCode == 0 && result.RetMsg == "OK" {
-		return "✅ 自动追加保证金设置成功"
+	switch result.RetCode {
+	case 0:
+		return &model.MarginResult{Status: model.MarginStatusSuccess, Symbol: symbol}, nil
+	case 10001:
+		// 未修改（可能已是目标状态）
+		return &model.MarginResult{Status: model.MarginStatusUnchanged, Symbol: symbol, Code: "10001", Message: result.RetMsg}, nil
 	}
-	if result.RetCode == 10001 {
-		return "⚠️ 自动追加保证金设置未修改（可能已是目标状态）"
+	return marginFailed(symbol, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg})
+}
+
+// FetchBalance 查询统一账户中指定币种的余额
+func (m *ByBit) FetchBalance(asset string) (*model.Balance, error) {
+	params := map[string]interface{}{"accountType": "UNIFIED", "coin": asset}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetAccountWallet(withPriority(context.Background(), PriorityMargin))
+	if err != nil {
+		log.Printf("[%s] Fetch Balance Error: %v", m.Name, err.Error())
+		return nil, err
+	}
+	if result.RetCode != 0 {
+		return nil, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	wallet, err := mapToStruct[model.ByBitWalletBalance](result.Result)
+	if err != nil {
+		log.Printf("[%s] Fetch Balance Error: %v", m.Name, err.Error())
+		return nil, err
+	}
+
+	balance := &model.Balance{Asset: asset}
+	for _, account := range wallet.List {
+		for _, coin := range account.Coin {
+			if coin.Coin != asset {
+				continue
+			}
+			balance.Total = parseFloat(coin.WalletBalance)
+			// 可用 = 钱包余额 - 持仓占用 - 挂单占用 - 冻结
+			balance.Available = balance.Total - parseFloat(coin.TotalPositionIM) - parseFloat(coin.TotalOrderIM) - parseFloat(coin.Locked)
+		}
+	}
+	return balance, nil
+}
+
+func (m *ByBit) Capabilities() Capabilities {
+	caps := newCapabilities(CapAddMargin, CapReduceMargin, CapAutoAddMargin, CapHedgeMode, CapTransfer,
+		CapSubAccountTransfer, CapAccountRisk, CapReducePosition, CapStream, CapCandles)
+	if m.UID == "" {
+		caps = caps.disable(CapSubAccountTransfer, "master uid not configured")
 	}
-	return "❌ 响应解析失败，接口调用异常"
+	return caps
 }
 
 func (m *ByBit) GetName() string {
-	return "ByBit"
+	return m.Name
+}
+
+// positionIdx 单向持仓为 0，双向持仓多头为 1、空头为 2
+func positionIdx(ps model.Position) int {
+	switch {
+	case !ps.Hedged:
+		return 0
+	case ps.Side == model.SideShort:
+		return 2
+	}
+	return 1
+}
+
+// fromByBitPosition 将 ByBit 原始持仓转换为 model.Position
+func fromByBitPosition(ps model.ByBitPosition) model.Position {
+	position := model.Position{
+		Symbol:            ps.Symbol,
+		Size:              parseFloat(ps.Size),
+		EntryPrice:        parseFloat(ps.AvgPrice),
+		MarkPrice:         parseFloat(ps.MarkPrice),
+		LiquidationPrice:  parseFloat(ps.LiqPrice),
+		MarginMode:        model.MarginModeCross,
+		InitialMargin:     parseFloat(ps.PositionIM),
+		MaintenanceMargin: parseFloat(ps.PositionMM),
+		Leverage:          parseFloat(ps.Leverage),
+		UnrealizedPnl:     parseFloat(ps.UnrealisedPnl),
+		AutoAddMargin:     ps.AutoAddMargin == 1,
+		Hedged:            ps.PositionIdx != 0,
+	}
+	// WebSocket 推送使用 entryPrice 字段
+	if position.EntryPrice == 0 {
+		position.EntryPrice = parseFloat(ps.EntryPrice)
+	}
+	switch ps.Side {
+	case "Buy":
+		position.Side = model.SideLong
+	case "Sell":
+		position.Side = model.SideShort
+	}
+	// tradeMode: 0 全仓, 1 逐仓（仅经典账户有效，统一账户由 riskPosition 按账户保证金模式覆盖）
+	if ps.TradeMode == 1 {
+		position.MarginMode = model.MarginModeIsolated
+	}
+	// 保证金率 = 维持保证金 / 仓位保证金
+	if balance := parseFloat(ps.PositionBalance); balance > 0 {
+		position.MarginRatio = position.MaintenanceMargin / balance
+	}
+	return position
+}
+
+func parseFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
 }
 
 func mapToStruct[T any](m interface{}) (*T, error) {
@@ -73,3 +332,24 @@ func mapToStruct[T any](m interface{}) (*T, error) {
 	}
 	return &result, nil
 }
+
+// observeByBitLimit 读取 X-Bapi-Limit-Status 剩余次数，接近上限或用尽时暂停轮询；
+// 该响应头按接口计数，某个查询接口用尽不影响追加保证金接口，只有 IP 级限制（403 / 429）才暂停所有请求
+func observeByBitLimit(limiter *RateLimiter, resp *http.Response) {
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		limiter.Pause(time.Now().Add(retryAfter(resp, bybitIPBanDuration)))
+		return
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-Bapi-Limit-Status"))
+	if err != nil {
+		return
+	}
+	resetMs, err := strconv.ParseInt(resp.Header.Get("X-Bapi-Limit-Reset-Timestamp"), 10, 64)
+	if err != nil {
+		return
+	}
+	reset := time.UnixMilli(resetMs)
+	if remaining <= bybitPollMinRemaining {
+		limiter.PausePolling(reset)
+	}
+}
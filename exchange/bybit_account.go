@@ -0,0 +1,204 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"margin_monitor/model"
+	"strconv"
+	"time"
+)
+
+// FetchAccountRisk 查询统一账户的全仓维持保证金率，统一账户以 USD 计价，asset 仅用于标记
+func (m *ByBit) FetchAccountRisk(asset string) (*model.AccountRisk, error) {
+	params := map[string]interface{}{"accountType": "UNIFIED"}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetAccountWallet(withPriority(context.Background(), PriorityPoll))
+	if err != nil {
+		log.Printf("[%s] Fetch Account Error: %v", m.Name, err)
+		return nil, err
+	}
+	if result.RetCode != 0 {
+		return nil, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	wallet, err := mapToStruct[model.ByBitWalletBalance](result.Result)
+	if err != nil {
+		return nil, err
+	}
+	if len(wallet.List) == 0 {
+		return nil, fmt.Errorf("[%s] unified account not found", m.Name)
+	}
+	account := wallet.List[0]
+	return &model.AccountRisk{
+		Asset:             asset,
+		Equity:            parseFloat(account.TotalMarginBalance),
+		InitialMargin:     parseFloat(account.TotalInitialMargin),
+		MaintenanceMargin: parseFloat(account.TotalMaintenanceMargin),
+		Available:         parseFloat(account.TotalAvailableBalance),
+		MarginRatio:       parseFloat(account.AccountMMRate),
+	}, nil
+}
+
+// ReducePosition 以只减仓市价单减仓 size，数量按合约 qtyStep 向下取整
+func (m *ByBit) ReducePosition(ps model.Position, size float64) (*model.MarginResult, error) {
+	category := m.category(ps.Symbol)
+	instrument, err := m.instrument(category, ps.Symbol)
+	if err != nil {
+		log.Printf("❌ [%s] reduce %s error: %v", m.Name, ps.Symbol, err)
+		return marginFailed(ps.Symbol, err)
+	}
+	size, qty := floorToStep(size, instrument.qtyStep)
+	if size <= 0 {
+		return marginFailed(ps.Symbol, fmt.Errorf("%w %s", ErrBelowQtyStep, instrument.qtyStep))
+	}
+	side := "Sell"
+	if ps.Side == model.SideShort {
+		side = "Buy"
+	}
+	params := map[string]interface{}{
+		"category":    category,
+		"symbol":      ps.Symbol,
+		"side":        side,
+		"orderType":   "Market",
+		"qty":         qty,
+		"reduceOnly":  true,
+		"positionIdx": positionIdx(ps),
+	}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).PlaceOrder(withPriority(context.Background(), PriorityMargin))
+	if err == nil && result.RetCode != 0 {
+		err = &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	if err != nil {
+		log.Printf("❌ [%s] reduce %s %.6f error: %v", m.Name, ps.Symbol, size, err)
+		return marginFailed(ps.Symbol, err)
+	}
+	data, _ := result.Result.(map[string]interface{})
+	log.Printf("✂️ [%s] reduced %s %s by %.6f, order %s", m.Name, ps.Symbol, ps.Side, size, mapString(data, "orderId"))
+	return &model.MarginResult{
+		Status: model.MarginStatusSuccess,
+		Symbol: ps.Symbol,
+		Amount: size,
+		TxID:   mapString(data, "orderId"),
+	}, nil
+}
+
+// fetchBrackets 查询 U 本位（linear）交易对的风险限额档位，维持保证金 = 价值 × maintenanceMargin - mmDeduction
+func (m *ByBit) fetchBrackets(symbol string) (map[string][]model.Bracket, error) {
+	params := map[string]interface{}{"category": "linear", "symbol": symbol}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetMarketRiskLimits(withPriority(context.Background(), PriorityPoll))
+	if err != nil {
+		return nil, err
+	}
+	if result.RetCode != 0 {
+		return nil, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	limits, err := mapToStruct[model.ByBitRiskLimitList](result.Result)
+	if err != nil {
+		return nil, err
+	}
+	brackets := make(map[string][]model.Bracket)
+	for _, limit := range limits.List {
+		brackets[limit.Symbol] = append(brackets[limit.Symbol], model.Bracket{
+			Cap:             parseFloat(limit.RiskLimitValue),
+			MaintMarginRate: parseFloat(limit.MaintenanceMargin),
+			MaintAmount:     parseFloat(limit.MmDeduction),
+			MaxLeverage:     parseFloat(limit.MaxLeverage),
+		})
+	}
+	return brackets, nil
+}
+
+// riskPosition 转换持仓并按合约信息设置保证金币种，U 本位合约按风险限额档位自算维持保证金、保证金率与强平价，
+// 反向合约以标的币计价，仍使用交易所返回值。轮询与推送共用，保证两条路径的持仓一致
+func (m *ByBit) riskPosition(ps model.ByBitPosition) (model.Position, error) {
+	category := m.category(ps.Symbol)
+	position := fromByBitPosition(ps)
+	asset, err := m.marginAsset(category, ps.Symbol)
+	if err != nil {
+		return position, err
+	}
+	position.MarginAsset = asset
+	// 统一账户 2.0 的 tradeMode 固定为 0，逐仓 / 全仓由账户保证金模式决定，查询失败时保留 tradeMode 的结果
+	if mode, err := m.accountMarginMode(); err != nil {
+		log.Printf("⚠️ [%s] fetch account margin mode error: %v", m.Name, err)
+	} else if mode == bybitIsolatedMargin {
+		position.MarginMode = model.MarginModeIsolated
+	} else {
+		position.MarginMode = model.MarginModeCross
+	}
+	if category == "linear" {
+		m.Brackets.apply(ps.Symbol, &position, parseFloat(ps.PositionBalance))
+	}
+	return position, nil
+}
+
+// bybitInstrument 合约信息中需要的字段
+type bybitInstrument struct {
+	settleCoin string
+	qtyStep    string
+}
+
+// marginAsset 返回合约的结算币种（反向合约为标的币，如 BTCUSD、BTCUSDH25 -> BTC）
+func (m *ByBit) marginAsset(category string, symbol string) (string, error) {
+	instrument, err := m.instrument(category, symbol)
+	if err != nil {
+		return "", err
+	}
+	return instrument.settleCoin, nil
+}
+
+// instrument 查询合约信息，按交易对缓存
+func (m *ByBit) instrument(category string, symbol string) (bybitInstrument, error) {
+	if cached, ok := m.instruments.Load(symbol); ok {
+		return cached.(bybitInstrument), nil
+	}
+	params := map[string]interface{}{"category": category, "symbol": symbol}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetInstrumentInfo(withPriority(context.Background(), PriorityPoll))
+	if err != nil {
+		return bybitInstrument{}, fmt.Errorf("fetch instrument %s error: %w", symbol, err)
+	}
+	if result.RetCode != 0 {
+		return bybitInstrument{}, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	info, err := mapToStruct[model.ByBitInstrumentList](result.Result)
+	if err != nil {
+		return bybitInstrument{}, err
+	}
+	for _, item := range info.List {
+		if item.Symbol != symbol {
+			continue
+		}
+		instrument := bybitInstrument{settleCoin: item.SettleCoin, qtyStep: item.LotSizeFilter.QtyStep}
+		if instrument.settleCoin == "" && category == "inverse" {
+			instrument.settleCoin = item.BaseCoin
+		}
+		if instrument.settleCoin == "" {
+			break
+		}
+		m.instruments.Store(symbol, instrument)
+		return instrument, nil
+	}
+	return bybitInstrument{}, fmt.Errorf("instrument %s (%s) not found", symbol, category)
+}
+
+// accountMarginMode 查询统一账户保证金模式（ISOLATED_MARGIN / REGULAR_MARGIN / PORTFOLIO_MARGIN），缓存 bybitMarginModeTTL
+func (m *ByBit) accountMarginMode() (string, error) {
+	m.marginModeMu.Lock()
+	defer m.marginModeMu.Unlock()
+	if time.Now().Before(m.marginModeExpires) {
+		return m.marginMode, nil
+	}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(map[string]interface{}{}).GetAccountInfo(withPriority(context.Background(), PriorityPoll))
+	if err != nil {
+		return "", err
+	}
+	if result.RetCode != 0 {
+		return "", &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	info, err := mapToStruct[model.ByBitAccountInfo](result.Result)
+	if err != nil {
+		return "", err
+	}
+	m.marginMode = info.MarginMode
+	m.marginModeExpires = time.Now().Add(bybitMarginModeTTL)
+	return m.marginMode, nil
+}
@@ -0,0 +1,58 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"margin_monitor/model"
+	"sort"
+	"strconv"
+)
+
+// bybitIntervals 通用 K 线周期到 ByBit interval 的映射
+var bybitIntervals = map[string]string{
+	"1m": "1", "5m": "5", "15m": "15", "30m": "30", "1h": "60", "4h": "240", "1d": "D",
+}
+
+// FetchCandles 查询 K 线，ByBit 按时间倒序返回，这里转为升序
+func (m *ByBit) FetchCandles(symbol string, interval string, limit int) ([]model.Candle, error) {
+	bybitInterval, ok := bybitIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("[%s] unsupported candle interval %s", m.Name, interval)
+	}
+	params := map[string]interface{}{
+		"category": m.category(symbol),
+		"symbol":   symbol,
+		"interval": bybitInterval,
+		"limit":    limit,
+	}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetMarketKline(withPriority(context.Background(), PriorityPoll))
+	if err != nil {
+		return nil, err
+	}
+	if result.RetCode != 0 {
+		return nil, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	// list 每项为 [startTime, open, high, low, close, volume, turnover]
+	kline, err := mapToStruct[struct {
+		List [][]string `json:"list"`
+	}](result.Result)
+	if err != nil {
+		return nil, err
+	}
+	candles := make([]model.Candle, 0, len(kline.List))
+	for _, row := range kline.List {
+		if len(row) < 5 {
+			continue
+		}
+		start, _ := strconv.ParseInt(row[0], 10, 64)
+		candles = append(candles, model.Candle{
+			Time:  start,
+			Open:  parseFloat(row[1]),
+			High:  parseFloat(row[2]),
+			Low:   parseFloat(row[3]),
+			Close: parseFloat(row[4]),
+		})
+	}
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Time < candles[j].Time })
+	return candles, nil
+}
@@ -0,0 +1,168 @@
+package exchange
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"margin_monitor/model"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	bybitPingInterval    = 20 * time.Second
+	bybitReconnectDelay  = 5 * time.Second
+	bybitAuthExpireAfter = 10 * time.Second
+	// bybitReadTimeout 超过两个心跳周期没有收到任何消息（包括 pong）视为连接已失效
+	bybitReadTimeout = 2 * bybitPingInterval
+)
+
+// bybitStreamMessage 私有频道消息，op 为请求响应，topic 为数据推送
+type bybitStreamMessage struct {
+	Op      string          `json:"op"`
+	Success *bool           `json:"success"`
+	RetMsg  string          `json:"ret_msg"`
+	Topic   string          `json:"topic"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Subscribe 订阅 position / wallet 私有频道，断线后自动重连并重新鉴权
+func (m *ByBit) Subscribe(ctx context.Context, events chan<- model.PositionEvent) error {
+	for {
+		err := m.stream(ctx, events)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("⚠️ [%s] private stream disconnected: %v, reconnecting in %s", m.Name, err, bybitReconnectDelay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(bybitReconnectDelay):
+		}
+	}
+}
+
+// stream 建立一次私有频道连接，鉴权、订阅并转发推送，连接断开时返回
+func (m *ByBit) stream(ctx context.Context, events chan<- model.PositionEvent) error {
+	conn, _, err := m.Dialer.DialContext(ctx, m.StreamURL, nil)
+	if err != nil {
+		return fmt.Errorf("dial private stream: %w", err)
+	}
+	defer conn.Close()
+
+	// gorilla/websocket 不支持并发写，心跳与请求共用同一把锁
+	var writeMu sync.Mutex
+	send := func(op string, args ...interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(map[string]interface{}{"op": op, "args": args})
+	}
+
+	expires := time.Now().Add(bybitAuthExpireAfter).UnixMilli()
+	if err := send("auth", m.Key, expires, m.streamSign(expires)); err != nil {
+		return fmt.Errorf("send auth: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(bybitPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				if err := send("ping"); err != nil {
+					log.Printf("⚠️ [%s] ping error: %v", m.Name, err)
+				}
+			}
+		}
+	}()
+
+	for {
+		// 半开连接不会返回错误，靠读超时发现并重连；pong 也是普通消息，每次读到都会延长
+		if err := conn.SetReadDeadline(time.Now().Add(bybitReadTimeout)); err != nil {
+			return err
+		}
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var msg bybitStreamMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			log.Printf("⚠️ [%s] invalid stream message: %v", m.Name, err)
+			continue
+		}
+
+		switch {
+		case msg.Op == "auth":
+			if msg.Success == nil || !*msg.Success {
+				return fmt.Errorf("auth failed: %s", msg.RetMsg)
+			}
+			log.Printf("[%s] private stream authenticated", m.Name)
+			if err := send("subscribe", "position", "wallet"); err != nil {
+				return fmt.Errorf("send subscribe: %w", err)
+			}
+		case msg.Op == "subscribe":
+			if msg.Success == nil || !*msg.Success {
+				return fmt.Errorf("subscribe failed: %s", msg.RetMsg)
+			}
+		case msg.Topic != "":
+			event, ok := m.parseEvent(msg)
+			if !ok {
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// parseEvent position 推送直接带有风险数据，wallet 推送需要重新拉取持仓
+func (m *ByBit) parseEvent(msg bybitStreamMessage) (model.PositionEvent, bool) {
+	event := model.PositionEvent{Reason: msg.Topic}
+	if msg.Topic != "position" {
+		return event, true
+	}
+
+	var positions []model.ByBitPosition
+	if err := json.Unmarshal(msg.Data, &positions); err != nil {
+		log.Printf("⚠️ [%s] invalid position push: %v", m.Name, err)
+		return event, false
+	}
+	for i := range positions {
+		if parseFloat(positions[i].Size) == 0 {
+			continue
+		}
+		if positions[i].Category != "" {
+			m.categories.Store(positions[i].Symbol, positions[i].Category)
+		}
+		position, err := m.riskPosition(positions[i])
+		if err != nil {
+			// 无法确定保证金币种时不直接处理推送，改为重新拉取持仓
+			log.Printf("⚠️ [%s] position push %s: %v", m.Name, positions[i].Symbol, err)
+			return model.PositionEvent{Reason: msg.Topic}, true
+		}
+		event.Positions = append(event.Positions, position)
+	}
+	return event, len(event.Positions) > 0
+}
+
+// streamSign WebSocket 鉴权签名: hex(hmac_sha256("GET/realtime" + expires))
+func (m *ByBit) streamSign(expires int64) string {
+	mac := hmac.New(sha256.New, []byte(m.Secret))
+	mac.Write([]byte("GET/realtime" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
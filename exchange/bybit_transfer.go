@@ -0,0 +1,118 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+	"strconv"
+)
+
+// bybitAccountTypes 钱包名称与 ByBit accountType 对应关系
+// 统一账户（UTA）的现货资产就在 UNIFIED 中，没有独立的 SPOT 钱包，只能从资金账户划入
+var bybitAccountTypes = map[string]string{
+	WalletFunding: "FUND",
+}
+
+// FundingWallets 统一账户只有资金账户可作为划转来源
+func (m *ByBit) FundingWallets() []string {
+	return []string{WalletFunding}
+}
+
+// FetchWalletBalance 查询资金（funding）账户余额
+func (m *ByBit) FetchWalletBalance(wallet string, asset string) (*model.Balance, error) {
+	accountType, ok := bybitAccountTypes[wallet]
+	if !ok {
+		return nil, fmt.Errorf("[%s] unsupported wallet: %s", m.Name, wallet)
+	}
+	params := map[string]interface{}{"accountType": accountType, "coin": asset}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).GetAllCoinsBalance(withPriority(context.Background(), PriorityMargin))
+	if err != nil {
+		log.Printf("⚠️ [%s] Fetch %s balance error: %v", m.Name, wallet, err)
+		return nil, err
+	}
+	if result.RetCode != 0 {
+		return nil, &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	coins, err := mapToStruct[model.ByBitCoinsBalance](result.Result)
+	if err != nil {
+		return nil, err
+	}
+	balance := &model.Balance{Asset: asset}
+	for _, coin := range coins.Balance {
+		if coin.Coin == asset {
+			balance.Total = parseFloat(coin.WalletBalance)
+			balance.Available = parseFloat(coin.TransferBalance)
+		}
+	}
+	return balance, nil
+}
+
+// TransferToFutures 通过 inter-transfer 把资金账户的资产转入统一账户
+func (m *ByBit) TransferToFutures(wallet string, asset string, amount float64) (*model.TransferResult, error) {
+	accountType, ok := bybitAccountTypes[wallet]
+	if !ok {
+		return nil, fmt.Errorf("[%s] unsupported wallet: %s", m.Name, wallet)
+	}
+	transferId := uuid.NewString()
+	params := map[string]interface{}{
+		"transferId":      transferId,
+		"coin":            asset,
+		"amount":          strconv.FormatFloat(amount, 'f', -1, 64),
+		"fromAccountType": accountType,
+		"toAccountType":   "UNIFIED",
+	}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).CreateInternalTransfer(withPriority(context.Background(), PriorityMargin))
+	if err != nil {
+		log.Printf("❌ [%s] transfer %s %.4f %s -> UNIFIED error: %v", m.Name, asset, amount, accountType, err)
+		return nil, err
+	}
+	if result.RetCode != 0 {
+		err := &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+		log.Printf("❌ [%s] transfer %s %.4f %s -> UNIFIED error: %v", m.Name, asset, amount, accountType, err)
+		return nil, err
+	}
+	log.Printf("💸 [%s] transferred %.4f %s %s -> UNIFIED, transferId %s", m.Name, amount, asset, accountType, transferId)
+	return &model.TransferResult{
+		TxID:   transferId,
+		Asset:  asset,
+		Amount: amount,
+		From:   wallet,
+		To:     WalletFutures,
+	}, nil
+}
+
+// FundSubAccount 主账户资金账户通过 universal-transfer 向子账户统一账户转入资产
+func (m *ByBit) FundSubAccount(sub config.SubAccount, asset string, amount float64) (*model.TransferResult, error) {
+	if m.UID == "" || sub.UID == "" {
+		return nil, fmt.Errorf("[%s] master uid or sub-account %s uid not set", m.Name, sub.Name)
+	}
+	transferId := uuid.NewString()
+	params := map[string]interface{}{
+		"transferId":      transferId,
+		"coin":            asset,
+		"amount":          strconv.FormatFloat(amount, 'f', -1, 64),
+		"fromMemberId":    m.UID,
+		"toMemberId":      sub.UID,
+		"fromAccountType": "FUND",
+		"toAccountType":   "UNIFIED",
+	}
+	result, err := m.Exchange.NewUtaBybitServiceWithParams(params).CreateUniversalTransfer(withPriority(context.Background(), PriorityMargin))
+	if err == nil && result.RetCode != 0 {
+		err = &APIError{Exchange: "ByBit", Code: strconv.Itoa(result.RetCode), Msg: result.RetMsg}
+	}
+	if err != nil {
+		log.Printf("❌ [%s] transfer %.4f %s to sub-account %s error: %v", m.Name, amount, asset, sub.Name, err)
+		return nil, err
+	}
+	log.Printf("💸 [%s] transferred %.4f %s to sub-account %s, transferId %s", m.Name, amount, asset, sub.Name, transferId)
+	return &model.TransferResult{
+		TxID:   transferId,
+		Asset:  asset,
+		Amount: amount,
+		From:   WalletFunding,
+		To:     sub.Name,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package exchange
+
+// Capability 适配器可能支持的功能
+type Capability string
+
+const (
+	CapAddMargin          Capability = "add margin"
+	CapReduceMargin       Capability = "reduce margin"
+	CapAutoAddMargin      Capability = "auto add margin"
+	CapHedgeMode          Capability = "hedge mode"
+	CapTransfer           Capability = "wallet transfer"
+	CapSubAccountTransfer Capability = "sub-account transfer"
+	CapAccountRisk        Capability = "account risk"
+	CapReducePosition     Capability = "reduce position"
+	CapStream             Capability = "position stream"
+	CapCandles            Capability = "candles"
+)
+
+// AllCapabilities 启动报告中的展示顺序
+var AllCapabilities = []Capability{
+	CapAddMargin, CapReduceMargin, CapAutoAddMargin, CapHedgeMode, CapTransfer,
+	CapSubAccountTransfer, CapAccountRisk, CapReducePosition, CapStream, CapCandles,
+}
+
+// Capabilities 适配器的功能描述，未列出的功能视为不支持
+type Capabilities struct {
+	supported   map[Capability]bool
+	unsupported map[Capability]string // 适配器已实现但当前配置下不可用的原因
+}
+
+func newCapabilities(caps ...Capability) Capabilities {
+	c := Capabilities{
+		supported:   make(map[Capability]bool, len(caps)),
+		unsupported: make(map[Capability]string),
+	}
+	for _, capability := range caps {
+		c.supported[capability] = true
+	}
+	return c
+}
+
+// disable 当前配置下关闭某项功能并记录原因
+func (c Capabilities) disable(capability Capability, reason string) Capabilities {
+	delete(c.supported, capability)
+	c.unsupported[capability] = reason
+	return c
+}
+
+// Has 是否支持该功能
+func (c Capabilities) Has(capability Capability) bool {
+	return c.supported[capability]
+}
+
+// Reason 不支持该功能的原因
+func (c Capabilities) Reason(capability Capability) string {
+	if reason, ok := c.unsupported[capability]; ok {
+		return reason
+	}
+	return "not supported by adapter"
+}
@@ -0,0 +1,200 @@
+package exchange
+
+import (
+	"fmt"
+	ccxt "github.com/ccxt/ccxt/go/v4"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+)
+
+// CCXT 通用适配器，Name 可以是任意 ccxt 交易所 id（gate, kucoinfutures, mexc...）
+type CCXT struct {
+	Name     string
+	Id       string
+	Exchange ccxt.ICoreExchange
+}
+
+func NewCCXT(conf config.Exchange, proxy string) (Exchange, error) {
+	id := conf.Name
+	userConfig := map[string]interface{}{
+		"apiKey":   conf.ExchangeKey,
+		"secret":   conf.ExchangeSecret,
+		"password": conf.Passphrase,
+		"options": map[string]interface{}{
+			"defaultType": "swap",
+		},
+	}
+	if proxy != "" {
+		userConfig["httpsProxy"] = proxy
+		userConfig["wsProxy"] = proxy
+	}
+	exchange, ok := ccxt.DynamicallyCreateInstance(id, userConfig)
+	if !ok {
+		return nil, fmt.Errorf("ccxt exchange %s not found", id)
+	}
+
+	m := &CCXT{
+		Name:     accountName(id, conf),
+		Id:       id,
+		Exchange: exchange,
+	}
+	if !m.has("fetchPositions") {
+		return nil, fmt.Errorf("ccxt exchange %s does not support fetchPositions", id)
+	}
+	if !m.has("addMargin") {
+		log.Printf("⚠️ ccxt exchange %s does not support addMargin, margin will not be added", id)
+	}
+
+	if res := <-exchange.LoadMarkets(); res != nil {
+		if err, ok := res.(error); ok {
+			return nil, fmt.Errorf("ccxt exchange %s load markets error: %w", id, err)
+		}
+	}
+	return m, nil
+}
+
+func (m *CCXT) FetchPositions() ([]model.Position, error) {
+	res := <-m.Exchange.FetchPositions()
+	if err, ok := res.(error); ok {
+		log.Printf("⚠️ [%s] Fetch positions error: %v", m.Name, err)
+		return nil, err
+	}
+	positions, ok := res.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("[%s] unexpected positions response: %T", m.Name, res)
+	}
+	list := make([]model.Position, 0, len(positions))
+	for i := range positions {
+		if ps, ok := positions[i].(map[string]interface{}); ok {
+			list = append(list, positionFromMap(ps))
+		}
+	}
+	return list, nil
+}
+
+// AddMargin 使用 ccxt 统一 addMargin，统一接口没有持仓方向参数，双向持仓的交易所按交易所默认方向处理
+func (m *CCXT) AddMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	symbol := ps.Symbol
+	if !m.has("addMargin") {
+		return marginFailed(symbol, fmt.Errorf("%s does not support addMargin", m.Id))
+	}
+	return marginResultFromCCXT(m.Name, m.Id, symbol, <-m.Exchange.AddMargin(symbol, amount))
+}
+
+func (m *CCXT) ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	symbol := ps.Symbol
+	if !m.has("reduceMargin") {
+		return marginFailed(symbol, fmt.Errorf("%s does not support reduceMargin", m.Id))
+	}
+	return marginResultFromCCXT(m.Name, m.Id, symbol, <-m.Exchange.ReduceMargin(symbol, amount))
+}
+
+func (m *CCXT) FetchBalance(asset string) (*model.Balance, error) {
+	res := <-m.Exchange.FetchBalance()
+	if err, ok := res.(error); ok {
+		log.Printf("⚠️ [%s] Fetch balance error: %v", m.Name, err)
+		return nil, err
+	}
+	balances, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("[%s] unexpected balance response: %T", m.Name, res)
+	}
+	total, _ := balances["total"].(map[string]interface{})
+	free, _ := balances["free"].(map[string]interface{})
+	return &model.Balance{
+		Asset:     asset,
+		Total:     mapFloat(total, asset),
+		Available: mapFloat(free, asset),
+	}, nil
+}
+
+// Capabilities 按 ccxt 的 has 描述生成，统一接口没有持仓方向参数，不支持双向持仓
+func (m *CCXT) Capabilities() Capabilities {
+	caps := newCapabilities(CapAddMargin, CapReduceMargin)
+	if !m.has("addMargin") {
+		caps = caps.disable(CapAddMargin, fmt.Sprintf("ccxt %s has no addMargin", m.Id))
+	}
+	if !m.has("reduceMargin") {
+		caps = caps.disable(CapReduceMargin, fmt.Sprintf("ccxt %s has no reduceMargin", m.Id))
+	}
+	return caps
+}
+
+func (m *CCXT) GetName() string {
+	return m.Name
+}
+
+// has 检查交易所是否支持某个统一方法，模拟实现（emulated）也视为支持
+func (m *CCXT) has(method string) bool {
+	switch v := m.Exchange.GetHas()[method].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "emulated"
+	}
+	return false
+}
+
+// marginResultFromCCXT 解析 ccxt 统一的保证金调整结构，name 为账户名用于日志，id 为交易所名用于错误码
+func marginResultFromCCXT(name string, id string, symbol string, res interface{}) (*model.MarginResult, error) {
+	if err, ok := res.(error); ok {
+		log.Printf("❌ [%s] margin error: %s: %v", name, symbol, err)
+		return marginFailed(symbol, err)
+	}
+	data, ok := res.(map[string]interface{})
+	if !ok {
+		return marginFailed(symbol, fmt.Errorf("[%s] unexpected margin response: %T", name, res))
+	}
+	if status := mapString(data, "status"); status != "ok" {
+		info, _ := data["info"].(map[string]interface{})
+		return marginFailed(symbol, &APIError{Exchange: id, Code: mapString(info, "code"), Msg: fmt.Sprintf("status %q", status)})
+	}
+	return &model.MarginResult{
+		Status:    model.MarginStatusSuccess,
+		Symbol:    symbol,
+		Amount:    mapFloat(data, "amount"),
+		NewMargin: mapFloat(data, "total"),
+	}, nil
+}
+
+// positionFromMap 将 ccxt 统一持仓字典转换为 model.Position
+func positionFromMap(ps map[string]interface{}) model.Position {
+	return model.Position{
+		Symbol:            mapString(ps, "symbol"),
+		Side:              mapString(ps, "side"),
+		Size:              mapFloat(ps, "contracts"),
+		EntryPrice:        mapFloat(ps, "entryPrice"),
+		MarkPrice:         mapFloat(ps, "markPrice"),
+		LiquidationPrice:  mapFloat(ps, "liquidationPrice"),
+		MarginMode:        mapString(ps, "marginMode"),
+		MarginAsset:       settleAsset(mapString(ps, "symbol")),
+		InitialMargin:     mapFloat(ps, "initialMargin"),
+		MaintenanceMargin: mapFloat(ps, "maintenanceMargin"),
+		MarginRatio:       mapFloat(ps, "marginRatio"),
+		Leverage:          mapFloat(ps, "leverage"),
+		UnrealizedPnl:     mapFloat(ps, "unrealizedPnl"),
+		Hedged:            ps["hedged"] == true,
+	}
+}
+
+func mapString(m map[string]interface{}, key string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func mapFloat(m map[string]interface{}, key string) float64 {
+	switch v := m[key].(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	case string:
+		return parseFloat(v)
+	}
+	return 0
+}
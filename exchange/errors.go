@@ -0,0 +1,154 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"margin_monitor/model"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// ErrBelowQtyStep 数量按交易所下单步长取整后为 0，不会发出订单
+var ErrBelowQtyStep = errors.New("size below quantity step")
+
+// APIError 交易所返回的业务错误，Code 为交易所原始错误码
+type APIError struct {
+	Exchange string
+	Code     string
+	Msg      string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s error code %s: %s", e.Exchange, e.Code, e.Msg)
+}
+
+// ccxt 错误信息中带有交易所原始响应，如 binance {"code":-4046,"msg":"..."}
+var codePattern = regexp.MustCompile(`"code"\s*:\s*"?(-?\d+)`)
+
+// marginFailed 构造失败的保证金操作结果，并尽量带上交易所错误码
+func marginFailed(symbol string, err error) (*model.MarginResult, error) {
+	result := &model.MarginResult{
+		Status:  model.MarginStatusFailed,
+		Symbol:  symbol,
+		Message: err.Error(),
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		result.Code = apiErr.Code
+	} else if match := codePattern.FindStringSubmatch(err.Error()); match != nil {
+		result.Code = match[1]
+	}
+	return result, err
+}
+
+// ErrorKind 错误分类，决定是否重试
+type ErrorKind int
+
+const (
+	ErrorNetwork  ErrorKind = iota // 网络、超时、限流、5xx，可重试
+	ErrorAuth                      // 鉴权失败，重试无效
+	ErrorBusiness                  // 交易所业务错误，重试无效
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorNetwork:
+		return "network"
+	case ErrorAuth:
+		return "auth"
+	default:
+		return "business"
+	}
+}
+
+// authCodes 各交易所鉴权相关错误码
+var authCodes = map[string]map[string]bool{
+	"Binance": {"-1022": true, "-2008": true, "-2014": true, "-2015": true},
+	"ByBit":   {"10003": true, "10004": true, "10005": true, "10007": true, "33004": true},
+	"OKX":     {"50100": true, "50111": true, "50112": true, "50113": true, "50114": true},
+	"Bitget":  {"40006": true, "40009": true, "40012": true, "40037": true},
+}
+
+// retryCodes 各交易所限流、时间戳、服务繁忙等可重试的错误码
+var retryCodes = map[string]map[string]bool{
+	"Binance": {"-1001": true, "-1003": true, "-1007": true, "-1021": true},
+	"ByBit":   {"10002": true, "10006": true, "10016": true},
+	"OKX":     {"50001": true, "50004": true, "50011": true, "50013": true},
+	"Bitget":  {"40010": true, "429": true},
+}
+
+// notSentPatterns 连接阶段的错误，请求一定没有到达交易所
+// 适配器用 %v 包装错误后 errors.As 失效，只能按错误信息判断
+var notSentPatterns = []string{"dial tcp", "no such host", "connection refused", "proxyconnect", "network is unreachable"}
+
+// NotSent 请求是否确定没有发出，写操作只在这种情况下可以安全重试
+func NotSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	msg := err.Error()
+	for _, pattern := range notSentPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ccxt 错误类型名称
+var (
+	ccxtAuthErrors    = []string{"AuthenticationError", "PermissionDenied", "AccountSuspended"}
+	ccxtNetworkErrors = []string{"NetworkError", "RequestTimeout", "ExchangeNotAvailable", "DDoSProtection", "RateLimitExceeded", "OnMaintenance"}
+)
+
+var statusPattern = regexp.MustCompile(`status (?:code: )?(\d{3})`)
+
+// Classify 对交易所调用返回的错误分类，无法识别的错误按业务错误处理
+func Classify(err error) ErrorKind {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case authCodes[apiErr.Exchange][apiErr.Code]:
+			return ErrorAuth
+		case retryCodes[apiErr.Exchange][apiErr.Code]:
+			return ErrorNetwork
+		}
+		return ErrorBusiness
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return ErrorNetwork
+	}
+
+	msg := err.Error()
+	for _, name := range ccxtAuthErrors {
+		if strings.Contains(msg, name) {
+			return ErrorAuth
+		}
+	}
+	for _, name := range ccxtNetworkErrors {
+		if strings.Contains(msg, name) {
+			return ErrorNetwork
+		}
+	}
+	if match := statusPattern.FindStringSubmatch(msg); match != nil {
+		switch {
+		case match[1] == "401" || match[1] == "403":
+			return ErrorAuth
+		case match[1] == "429" || match[1][0] == '5':
+			return ErrorNetwork
+		}
+	}
+	if strings.Contains(msg, "failed to send request") {
+		return ErrorNetwork
+	}
+	return ErrorBusiness
+}
@@ -0,0 +1,43 @@
+package exchange
+
+import (
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// newHTTPClient 创建直连 REST 接口使用的 http.Client，支持代理
+func newHTTPClient(proxy string) *http.Client {
+	transport := &http.Transport{}
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			log.Printf("Invalid proxy URL: %v\n", err)
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   10 * time.Second,
+	}
+}
+
+// newWSDialer 创建 WebSocket 拨号器，支持代理
+func newWSDialer(proxy string) *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 10 * time.Second,
+	}
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			log.Printf("Invalid proxy URL: %v\n", err)
+		} else {
+			dialer.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	return dialer
+}
@@ -0,0 +1,341 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	hyperliquidBaseURL        = "https://api.hyperliquid.xyz"
+	hyperliquidTestnetBaseURL = "https://api.hyperliquid-testnet.xyz"
+	hyperliquidMarginAsset    = "USDC"
+	// Hyperliquid 按 IP 每分钟 1200 权重，info 查询按 2 计，保留部分给保证金操作
+	hyperliquidWeightLimit    = 1200
+	hyperliquidWeightReserved = 200
+	hyperliquidInfoWeight     = 2
+)
+
+// Hyperliquid 永续合约，Address 为主账户钱包地址，PrivateKey 为 API 钱包（agent）私钥
+type Hyperliquid struct {
+	Name       string
+	BaseURL    string
+	Address    string
+	PrivateKey []byte
+	Testnet    bool
+	Limiter    *RateLimiter
+	Client     *http.Client
+
+	mu     sync.Mutex
+	assets map[string]int // coin -> asset 编号（meta universe 下标）
+}
+
+// NewHyperliquid exchangeKey 填钱包地址，exchangeSecret 填 API 钱包私钥（hex），地址为空时由私钥推导
+func NewHyperliquid(conf config.Exchange, proxy string) Exchange {
+	name := accountName("Hyperliquid", conf)
+	limiter := NewRateLimiter(name, hyperliquidWeightLimit, hyperliquidWeightReserved, time.Minute)
+	m := &Hyperliquid{
+		Name:    name,
+		BaseURL: hyperliquidBaseURL,
+		Address: strings.ToLower(conf.ExchangeKey),
+		Testnet: conf.Testnet,
+		Limiter: limiter,
+		Client:  limitClient(newHTTPClient(proxy), limiter, observeHyperliquidLimit),
+	}
+	if conf.Testnet {
+		m.BaseURL = hyperliquidTestnetBaseURL
+	}
+	key, err := parsePrivateKey(conf.ExchangeSecret)
+	if err != nil {
+		log.Printf("[%s] Invalid private key, margin changes are disabled: %v", m.Name, err)
+	} else {
+		m.PrivateKey = key
+		if m.Address == "" {
+			m.Address = walletAddress(key)
+		}
+	}
+	return m
+}
+
+func (m *Hyperliquid) FetchPositions() ([]model.Position, error) {
+	state, err := m.clearinghouseState(context.Background())
+	if err != nil {
+		log.Printf("[%s] Fetch Positions Error: %v", m.Name, err)
+		return nil, err
+	}
+	list := make([]model.Position, 0, len(state.AssetPositions))
+	for _, item := range state.AssetPositions {
+		if parseFloat(item.Position.Szi) == 0 {
+			continue
+		}
+		list = append(list, fromHyperliquidPosition(item.Position, state))
+	}
+	return list, nil
+}
+
+// AddMargin 追加逐仓保证金
+func (m *Hyperliquid) AddMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, amount)
+}
+
+// ReduceMargin 减少逐仓保证金
+func (m *Hyperliquid) ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, -amount)
+}
+
+// changeMargin 通过 updateIsolatedMargin 签名操作调整逐仓保证金，amount 为正追加、为负减少
+func (m *Hyperliquid) changeMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	symbol := ps.Symbol
+	ctx := withPriority(context.Background(), PriorityMargin)
+	asset, err := m.assetIndex(ctx, symbol)
+	if err != nil {
+		log.Printf("[%s] Change Margin Error: %v", m.Name, err)
+		return marginFailed(symbol, err)
+	}
+	action := hyperliquidMarginAction{
+		Type:  "updateIsolatedMargin",
+		Asset: asset,
+		IsBuy: ps.Side == model.SideLong,
+		// USDC 精度为 6 位，ntli 为放大 1e6 后的整数
+		Ntli: int64(math.Round(amount * 1e6)),
+	}
+	if err := m.exchange(ctx, action); err != nil {
+		log.Printf("[%s] Change Margin Error: %v", m.Name, err)
+		return marginFailed(symbol, err)
+	}
+	return &model.MarginResult{
+		Status: model.MarginStatusSuccess,
+		Symbol: symbol,
+		Amount: math.Abs(amount),
+	}, nil
+}
+
+// FetchBalance 只有 USDC 保证金，Total 为账户权益，Available 为可提取金额
+func (m *Hyperliquid) FetchBalance(asset string) (*model.Balance, error) {
+	balance := &model.Balance{Asset: asset}
+	if asset != hyperliquidMarginAsset {
+		return balance, nil
+	}
+	state, err := m.clearinghouseState(withPriority(context.Background(), PriorityMargin))
+	if err != nil {
+		log.Printf("[%s] Fetch Balance Error: %v", m.Name, err)
+		return nil, err
+	}
+	balance.Total = parseFloat(state.MarginSummary.AccountValue)
+	balance.Available = parseFloat(state.Withdrawable)
+	return balance, nil
+}
+
+// FetchAccountRisk 查询全仓账户风险，保证金率为全仓维持保证金 / 全仓权益
+func (m *Hyperliquid) FetchAccountRisk(asset string) (*model.AccountRisk, error) {
+	state, err := m.clearinghouseState(withPriority(context.Background(), PriorityMargin))
+	if err != nil {
+		log.Printf("[%s] Fetch Account Error: %v", m.Name, err)
+		return nil, err
+	}
+	risk := &model.AccountRisk{
+		Asset:             asset,
+		Equity:            parseFloat(state.CrossMarginSummary.AccountValue),
+		InitialMargin:     parseFloat(state.CrossMarginSummary.TotalMarginUsed),
+		MaintenanceMargin: parseFloat(state.CrossMaintenanceMarginUsed),
+		Available:         parseFloat(state.Withdrawable),
+	}
+	if risk.Equity > 0 {
+		risk.MarginRatio = risk.MaintenanceMargin / risk.Equity
+	}
+	return risk, nil
+}
+
+// Capabilities Hyperliquid 只有单向持仓，没有自动追加保证金
+func (m *Hyperliquid) Capabilities() Capabilities {
+	caps := newCapabilities(CapAddMargin, CapReduceMargin, CapAccountRisk)
+	if m.PrivateKey == nil {
+		caps = caps.disable(CapAddMargin, "exchangeSecret is not a valid API wallet private key")
+		caps = caps.disable(CapReduceMargin, "exchangeSecret is not a valid API wallet private key")
+	}
+	return caps
+}
+
+func (m *Hyperliquid) GetName() string {
+	return m.Name
+}
+
+// hyperliquidMarginAction updateIsolatedMargin 操作，字段顺序参与签名，不能调整
+type hyperliquidMarginAction struct {
+	Type  string `json:"type" msgpack:"type"`
+	Asset int    `json:"asset" msgpack:"asset"`
+	IsBuy bool   `json:"isBuy" msgpack:"isBuy"`
+	Ntli  int64  `json:"ntli" msgpack:"ntli"`
+}
+
+type hyperliquidSignature struct {
+	R string `json:"r"`
+	S string `json:"s"`
+	V byte   `json:"v"`
+}
+
+type hyperliquidExchangeRequest struct {
+	Action       interface{}          `json:"action"`
+	Nonce        int64                `json:"nonce"`
+	Signature    hyperliquidSignature `json:"signature"`
+	VaultAddress *string              `json:"vaultAddress"`
+}
+
+type hyperliquidExchangeResponse struct {
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+func (m *Hyperliquid) clearinghouseState(ctx context.Context) (*model.HyperliquidClearinghouseState, error) {
+	if m.Address == "" {
+		return nil, fmt.Errorf("wallet address is not configured")
+	}
+	var state model.HyperliquidClearinghouseState
+	body := map[string]string{"type": "clearinghouseState", "user": m.Address}
+	if err := m.post(ctx, "/info", body, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// assetIndex 返回 coin 对应的 asset 编号，找不到时重新加载 meta（可能是新上线的币）
+func (m *Hyperliquid) assetIndex(ctx context.Context, coin string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if index, ok := m.assets[coin]; ok {
+		return index, nil
+	}
+	var meta model.HyperliquidMeta
+	if err := m.post(ctx, "/info", map[string]string{"type": "meta"}, &meta); err != nil {
+		return 0, fmt.Errorf("fetch meta error: %w", err)
+	}
+	m.assets = make(map[string]int, len(meta.Universe))
+	for i, asset := range meta.Universe {
+		m.assets[asset.Name] = i
+	}
+	index, ok := m.assets[coin]
+	if !ok {
+		return 0, fmt.Errorf("unknown coin %s", coin)
+	}
+	return index, nil
+}
+
+// exchange 签名并提交操作，nonce 使用毫秒时间戳
+func (m *Hyperliquid) exchange(ctx context.Context, action hyperliquidMarginAction) error {
+	if m.PrivateKey == nil {
+		return fmt.Errorf("private key is not configured")
+	}
+	nonce := time.Now().UnixMilli()
+	signature, err := signL1Action(m.PrivateKey, action, nonce, !m.Testnet)
+	if err != nil {
+		return fmt.Errorf("sign error: %w", err)
+	}
+	request := hyperliquidExchangeRequest{
+		Action:    action,
+		Nonce:     nonce,
+		Signature: signature,
+	}
+	var result hyperliquidExchangeResponse
+	if err := m.post(ctx, "/exchange", request, &result); err != nil {
+		return err
+	}
+	if result.Status != "ok" {
+		var msg string
+		if json.Unmarshal(result.Response, &msg) != nil {
+			msg = string(result.Response)
+		}
+		return &APIError{Exchange: "Hyperliquid", Code: result.Status, Msg: msg}
+	}
+	return nil
+}
+
+// post 发送 JSON 请求，info 查询按 hyperliquidInfoWeight 计权重
+func (m *Hyperliquid) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal error: %w", err)
+	}
+	if path == "/info" {
+		// limitedTransport 每个请求计 1，这里补上剩余权重
+		if err := m.Limiter.Wait(ctx, priorityFrom(ctx), hyperliquidInfoWeight-1); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	return nil
+}
+
+// observeHyperliquidLimit Hyperliquid 没有权重响应头，只处理 429
+func observeHyperliquidLimit(limiter *RateLimiter, resp *http.Response) {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		limiter.Pause(time.Now().Add(retryAfter(resp, time.Minute)))
+	}
+}
+
+// fromHyperliquidPosition 将 Hyperliquid 原始持仓转换为 model.Position
+// 维持保证金为最大杠杆下初始保证金的一半；全仓持仓使用账户级全仓保证金率
+func fromHyperliquidPosition(ps model.HyperliquidPosition, state *model.HyperliquidClearinghouseState) model.Position {
+	size := parseFloat(ps.Szi)
+	value := parseFloat(ps.PositionValue)
+	position := model.Position{
+		Symbol:           ps.Coin,
+		Side:             model.SideLong,
+		Size:             math.Abs(size),
+		EntryPrice:       parseFloat(ps.EntryPx),
+		LiquidationPrice: parseFloat(ps.LiquidationPx),
+		MarginMode:       model.MarginModeIsolated,
+		MarginAsset:      hyperliquidMarginAsset,
+		InitialMargin:    parseFloat(ps.MarginUsed),
+		Leverage:         float64(ps.Leverage.Value),
+		UnrealizedPnl:    parseFloat(ps.UnrealizedPnl),
+	}
+	if size < 0 {
+		position.Side = model.SideShort
+	}
+	if position.Size > 0 {
+		position.MarkPrice = value / position.Size
+	}
+	if ps.MaxLeverage > 0 {
+		position.MaintenanceMargin = value / float64(2*ps.MaxLeverage)
+	}
+	if ps.Leverage.Type == "cross" {
+		position.MarginMode = model.MarginModeCross
+		if equity := parseFloat(state.CrossMarginSummary.AccountValue); equity > 0 {
+			position.MarginRatio = parseFloat(state.CrossMaintenanceMarginUsed) / equity
+		}
+	} else if position.InitialMargin > 0 {
+		position.MarginRatio = position.MaintenanceMargin / position.InitialMargin
+	}
+	return position
+}
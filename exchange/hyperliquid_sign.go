@@ -0,0 +1,93 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/crypto/sha3"
+)
+
+// Hyperliquid L1 操作签名：对 msgpack(action) + nonce + vault 标记求 keccak 得到 connectionId，
+// 再按 EIP-712 对 Agent{source, connectionId} 签名，主网 source 为 a，测试网为 b
+const hyperliquidChainID = 1337
+
+func keccak256(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		hash.Write(b)
+	}
+	return hash.Sum(nil)
+}
+
+// parsePrivateKey 解析 hex 私钥，允许 0x 前缀
+func parsePrivateKey(secret string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(secret), "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("private key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// walletAddress 由私钥推导以太坊地址（小写 hex）
+func walletAddress(key []byte) string {
+	pub := secp256k1.PrivKeyFromBytes(key).PubKey().SerializeUncompressed()
+	return "0x" + hex.EncodeToString(keccak256(pub[1:])[12:])
+}
+
+// actionHash 计算 connectionId，未使用 vault 时末尾追加 0x00
+func actionHash(action interface{}, nonce int64) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	// 与官方 SDK（Python msgpack）一致，整数按最短格式编码
+	enc.UseCompactInts(true)
+	if err := enc.Encode(action); err != nil {
+		return nil, err
+	}
+	buf.Write(binary.BigEndian.AppendUint64(nil, uint64(nonce)))
+	buf.WriteByte(0)
+	return keccak256(buf.Bytes()), nil
+}
+
+// agentDigest 计算 Agent 结构体的 EIP-712 签名摘要
+func agentDigest(source string, connectionID []byte) []byte {
+	domainType := keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	domain := keccak256(
+		domainType,
+		keccak256([]byte("Exchange")),
+		keccak256([]byte("1")),
+		big.NewInt(hyperliquidChainID).FillBytes(make([]byte, 32)),
+		make([]byte, 32), // verifyingContract 为零地址
+	)
+	agentType := keccak256([]byte("Agent(string source,bytes32 connectionId)"))
+	agent := keccak256(agentType, keccak256([]byte(source)), connectionID)
+	return keccak256([]byte{0x19, 0x01}, domain, agent)
+}
+
+// signL1Action 对 L1 操作签名，返回 r / s / v
+func signL1Action(key []byte, action interface{}, nonce int64, mainnet bool) (hyperliquidSignature, error) {
+	connectionID, err := actionHash(action, nonce)
+	if err != nil {
+		return hyperliquidSignature{}, err
+	}
+	source := "b"
+	if mainnet {
+		source = "a"
+	}
+	// SignCompact 返回 [27 + recid][r][s]
+	sig := ecdsa.SignCompact(secp256k1.PrivKeyFromBytes(key), agentDigest(source, connectionID), false)
+	return hyperliquidSignature{
+		R: "0x" + hex.EncodeToString(sig[1:33]),
+		S: "0x" + hex.EncodeToString(sig[33:65]),
+		V: sig[0],
+	}, nil
+}
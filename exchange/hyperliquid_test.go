@@ -0,0 +1,198 @@
+package exchange
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"margin_monitor/model"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+const (
+	hyperliquidTestMeta  = `{"universe":[{"name":"BTC","szDecimals":5,"maxLeverage":50},{"name":"ETH","szDecimals":4,"maxLeverage":50}]}`
+	hyperliquidTestState = `{
+		"assetPositions":[
+			{"type":"oneWay","position":{"coin":"ETH","szi":"-2.0","entryPx":"2000","positionValue":"4000","unrealizedPnl":"0","liquidationPx":"2150","marginUsed":"200","maxLeverage":50,"leverage":{"type":"isolated","value":20}}},
+			{"type":"oneWay","position":{"coin":"BTC","szi":"0.1","entryPx":"60000","positionValue":"6000","unrealizedPnl":"0","liquidationPx":null,"marginUsed":"600","maxLeverage":50,"leverage":{"type":"cross","value":10}}},
+			{"type":"oneWay","position":{"coin":"SOL","szi":"0.0","entryPx":"0","positionValue":"0","unrealizedPnl":"0","liquidationPx":null,"marginUsed":"0","maxLeverage":20,"leverage":{"type":"cross","value":5}}}
+		],
+		"marginSummary":{"accountValue":"1500","totalMarginUsed":"800"},
+		"crossMarginSummary":{"accountValue":"1000","totalMarginUsed":"600"},
+		"crossMaintenanceMarginUsed":"60",
+		"withdrawable":"300"
+	}`
+	// 官方 Python SDK 测试使用的私钥
+	hyperliquidTestKey = "0x0123456789012345678901234567890123456789012345678901234567890123"
+)
+
+// newHyperliquidTest 创建指向模拟服务器的 Hyperliquid 实例，info 按请求类型返回固定响应
+func newHyperliquidTest(t *testing.T, key []byte) (*Hyperliquid, *mockServer) {
+	t.Helper()
+	srv := newMockServer(t, func(r *http.Request, body []byte) string {
+		var req struct {
+			Type string `json:"type"`
+			User string `json:"user"`
+		}
+		json.Unmarshal(body, &req)
+		switch {
+		case r.URL.Path == "/info" && req.Type == "meta":
+			return hyperliquidTestMeta
+		case r.URL.Path == "/info" && req.Type == "clearinghouseState" && req.User == "0xabc":
+			return hyperliquidTestState
+		case r.URL.Path == "/exchange":
+			return `{"status":"ok","response":{"type":"default"}}`
+		}
+		return ""
+	})
+	limiter := NewRateLimiter("Hyperliquid", hyperliquidWeightLimit, hyperliquidWeightReserved, time.Minute)
+	return &Hyperliquid{
+		Name:       "Hyperliquid",
+		BaseURL:    srv.URL,
+		Address:    "0xabc",
+		PrivateKey: key,
+		Limiter:    limiter,
+		Client:     limitClient(srv.Client(), limiter, observeHyperliquidLimit),
+	}, srv
+}
+
+func TestHyperliquidFetchPositions(t *testing.T) {
+	m, _ := newHyperliquidTest(t, nil)
+	positions, err := m.FetchPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("expected 2 open positions, got %d", len(positions))
+	}
+
+	// 逐仓：维持保证金 = 价值 / (2 * 最大杠杆)，保证金率 = 维持保证金 / 仓位保证金
+	isolated := positions[0]
+	if isolated.Symbol != "ETH" || isolated.Side != model.SideShort || isolated.Size != 2 || isolated.MarginMode != model.MarginModeIsolated {
+		t.Errorf("isolated position %+v", isolated)
+	}
+	if isolated.MarkPrice != 2000 || isolated.MaintenanceMargin != 40 || isolated.MarginRatio != 0.2 || isolated.LiquidationPrice != 2150 {
+		t.Errorf("isolated risk %+v", isolated)
+	}
+
+	// 全仓：保证金率取账户级全仓维持保证金 / 全仓权益
+	cross := positions[1]
+	if cross.Symbol != "BTC" || cross.Side != model.SideLong || cross.MarginMode != model.MarginModeCross {
+		t.Errorf("cross position %+v", cross)
+	}
+	if cross.MarginRatio != 0.06 || cross.LiquidationPrice != 0 || cross.MarginAsset != hyperliquidMarginAsset {
+		t.Errorf("cross risk %+v", cross)
+	}
+
+	risk, err := m.FetchAccountRisk(hyperliquidMarginAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if risk.MarginRatio != 0.06 || risk.Equity != 1000 || risk.Available != 300 {
+		t.Errorf("account risk %+v", risk)
+	}
+}
+
+func TestHyperliquidAssetIndex(t *testing.T) {
+	m, _ := newHyperliquidTest(t, nil)
+	for coin, want := range map[string]int{"BTC": 0, "ETH": 1} {
+		index, err := m.assetIndex(context.Background(), coin)
+		if err != nil || index != want {
+			t.Errorf("asset %s: got %d %v, want %d", coin, index, err, want)
+		}
+	}
+	if _, err := m.assetIndex(context.Background(), "DOGE"); err == nil {
+		t.Error("expected unknown coin error")
+	}
+}
+
+func TestHyperliquidChangeMargin(t *testing.T) {
+	key, err := parsePrivateKey(hyperliquidTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, srv := newHyperliquidTest(t, key)
+	result, err := m.ReduceMargin(model.Position{Symbol: "ETH", Side: model.SideShort}, 12.5)
+	if err != nil || result.Status != model.MarginStatusSuccess || result.Amount != 12.5 {
+		t.Fatalf("result %+v, err %v", result, err)
+	}
+
+	var request struct {
+		Action       hyperliquidMarginAction `json:"action"`
+		Nonce        int64                   `json:"nonce"`
+		Signature    hyperliquidSignature    `json:"signature"`
+		VaultAddress *string                 `json:"vaultAddress"`
+	}
+	body := srv.last(t).Body
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatal(err)
+	}
+	want := hyperliquidMarginAction{Type: "updateIsolatedMargin", Asset: 1, IsBuy: false, Ntli: -12500000}
+	if request.Action != want {
+		t.Errorf("action %+v, want %+v", request.Action, want)
+	}
+	if request.VaultAddress != nil || !strings.Contains(string(body), `"vaultAddress":null`) {
+		t.Errorf("vaultAddress should be null: %s", body)
+	}
+
+	// 由签名恢复出的地址应为 API 钱包地址
+	connectionID, err := actionHash(request.Action, request.Nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ := new(big.Int).SetString(strings.TrimPrefix(request.Signature.R, "0x"), 16)
+	s, _ := new(big.Int).SetString(strings.TrimPrefix(request.Signature.S, "0x"), 16)
+	compact := append([]byte{request.Signature.V}, r.FillBytes(make([]byte, 32))...)
+	compact = append(compact, s.FillBytes(make([]byte, 32))...)
+	pub, _, err := ecdsa.RecoverCompact(compact, agentDigest("a", connectionID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := "0x" + hex.EncodeToString(keccak256(pub.SerializeUncompressed()[1:])[12:]); got != walletAddress(key) {
+		t.Errorf("recovered signer %s, want %s", got, walletAddress(key))
+	}
+}
+
+// TestSignL1Action 对照官方 Python SDK test_signing.py 的 test_l1_action_signing_matches
+func TestSignL1Action(t *testing.T) {
+	type dummyAction struct {
+		Type string `msgpack:"type"`
+		Num  int64  `msgpack:"num"`
+	}
+	key, err := parsePrivateKey(hyperliquidTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		mainnet bool
+		r, s    string
+		v       byte
+	}{
+		{"mainnet", true, "0x53749d5b30552aeb2fca34b530185976545bb22d0b3ce6f62e31be961a59298", "0x755c40ba9bf05223521753995abb2f73ab3229be8ec921f350cb447e384d8ed8", 27},
+		{"testnet", false, "0x542af61ef1f429707e3c76c5293c80d01f74ef853e34b76efffcb57e574f9510", "0x17b8b32f086e8cdede991f1e2c529f5dd5297cbe8128500e00cbaf766204a613", 28},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := signL1Action(key, dummyAction{Type: "dummy", Num: 100000000000}, 0, tt.mainnet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 官方 SDK 输出的 r / s 不补前导零，按数值比较
+			if !hexEqual(sig.R, tt.r) || !hexEqual(sig.S, tt.s) || sig.V != tt.v {
+				t.Errorf("signature %+v, want r %s s %s v %d", sig, tt.r, tt.s, tt.v)
+			}
+		})
+	}
+}
+
+func hexEqual(a, b string) bool {
+	x, ok := new(big.Int).SetString(strings.TrimPrefix(a, "0x"), 16)
+	y, ok2 := new(big.Int).SetString(strings.TrimPrefix(b, "0x"), 16)
+	return ok && ok2 && x.Cmp(y) == 0
+}
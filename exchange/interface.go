@@ -1,7 +1,83 @@
 package exchange
 
+import (
+	"context"
+	"margin_monitor/config"
+	"margin_monitor/model"
+)
+
+// accountName 交易所显示名称，带上账户标签与子账户名，如 Binance(main)/sub1
+func accountName(exchange string, conf config.Exchange) string {
+	name := exchange
+	if conf.Label != "" {
+		name += "(" + conf.Label + ")"
+	}
+	if conf.Sub != nil {
+		name += "/" + conf.Sub.Name
+	}
+	return name
+}
+
 type Exchange interface {
-	FetchPositions() (interface{}, error)
-	AddMargin(symbol string, amount float64) string
+	// FetchPositions 返回统一结构的持仓列表
+	FetchPositions() ([]model.Position, error)
+	// AddMargin 追加逐仓保证金，双向持仓时按 ps.Side 选择对应仓位，失败时同时返回带错误码的结果与 error
+	AddMargin(ps model.Position, amount float64) (*model.MarginResult, error)
+	// ReduceMargin 减少逐仓保证金，释放到合约钱包
+	ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error)
+	// FetchBalance 返回合约账户中指定币种的余额
+	FetchBalance(asset string) (*model.Balance, error)
+	// Capabilities 适配器支持的功能，控制器据此跳过不支持的操作
+	Capabilities() Capabilities
 	GetName() string
 }
+
+// AutoMarginer 支持交易所侧自动追加保证金的交易所
+type AutoMarginer interface {
+	// AutoAddEnabled 该交易对是否配置为使用自动追加保证金
+	AutoAddEnabled(symbol string) bool
+	SetAutoAddMargin(ps model.Position) (*model.MarginResult, error)
+}
+
+const (
+	WalletSpot    = "spot"
+	WalletFunding = "funding"
+	WalletFutures = "futures"
+)
+
+// Transferer 支持从现货 / 资金账户向合约账户划转的交易所
+type Transferer interface {
+	// FundingWallets 可作为划转来源的钱包，未配置 funding.from 时按此顺序尝试
+	FundingWallets() []string
+	// FetchWalletBalance 查询 spot / funding 钱包余额
+	FetchWalletBalance(wallet string, asset string) (*model.Balance, error)
+	// TransferToFutures 从 spot / funding 钱包划转到合约钱包
+	TransferToFutures(wallet string, asset string, amount float64) (*model.TransferResult, error)
+}
+
+// AccountRisker 支持查询账户级（全仓）保证金率的交易所
+type AccountRisker interface {
+	FetchAccountRisk(asset string) (*model.AccountRisk, error)
+}
+
+// ExposureReducer 支持以只减仓市价单降低持仓的交易所，全仓风险过高且无法补充保证金时使用
+type ExposureReducer interface {
+	ReducePosition(ps model.Position, size float64) (*model.MarginResult, error)
+}
+
+// SubAccountFunder 支持主账户向子账户合约钱包划转的交易所，由主账户实例实现
+type SubAccountFunder interface {
+	FundSubAccount(sub config.SubAccount, asset string, amount float64) (*model.TransferResult, error)
+}
+
+// Streamer 支持 WebSocket 推送持仓变化的交易所
+type Streamer interface {
+	// Subscribe 订阅账户推送并写入 events，内部自动重连，直到 ctx 结束
+	Subscribe(ctx context.Context, events chan<- model.PositionEvent) error
+}
+
+// CandleFetcher 支持查询 K 线的交易所，用于计算 ATR 强平距离
+type CandleFetcher interface {
+	// FetchCandles 返回按时间升序的最近 limit 根 K 线，interval 为 1m / 5m / 15m / 30m / 1h / 4h / 1d
+	FetchCandles(symbol string, interval string, limit int) ([]model.Candle, error)
+}
@@ -0,0 +1,63 @@
+package exchange
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordedRequest 模拟服务器收到的请求
+type recordedRequest struct {
+	Method string
+	Path   string // 含查询参数
+	Body   []byte
+	Header http.Header
+}
+
+// mockServer 模拟交易所 REST 接口并记录收到的请求
+type mockServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+// newMockServer respond 按请求返回响应体，返回空字符串时响应 404
+func newMockServer(t *testing.T, respond func(r *http.Request, body []byte) string) *mockServer {
+	t.Helper()
+	s := &mockServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, recordedRequest{Method: r.Method, Path: r.URL.RequestURI(), Body: body, Header: r.Header.Clone()})
+		s.mu.Unlock()
+		response := respond(r, body)
+		if response == "" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// byPath 按请求路径返回固定响应
+func byPath(responses map[string]string) func(r *http.Request, body []byte) string {
+	return func(r *http.Request, _ []byte) string {
+		return responses[r.URL.Path]
+	}
+}
+
+// last 返回最近一次请求
+func (s *mockServer) last(t *testing.T) recordedRequest {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		t.Fatal("no request received")
+	}
+	return s.requests[len(s.requests)-1]
+}
@@ -0,0 +1,251 @@
+package exchange
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const okxBaseURL = "https://www.okx.com"
+
+type OKX struct {
+	Name       string
+	BaseURL    string
+	Key        string
+	Secret     string
+	Passphrase string
+	Client     *http.Client
+}
+
+func NewOKX(conf config.Exchange, proxy string) Exchange {
+	return &OKX{
+		Name:       accountName("OKX", conf),
+		BaseURL:    okxBaseURL,
+		Key:        conf.ExchangeKey,
+		Secret:     conf.ExchangeSecret,
+		Passphrase: conf.Passphrase,
+		Client:     newHTTPClient(proxy),
+	}
+}
+
+type okxResponse struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (m *OKX) FetchPositions() ([]model.Position, error) {
+	var positions []model.OKXPosition
+	query := url.Values{"instType": {"SWAP"}}
+	if err := m.request(http.MethodGet, "/api/v5/account/positions", query, nil, &positions); err != nil {
+		log.Printf("[%s] Fetch Positions Error: %v", m.Name, err.Error())
+		return nil, err
+	}
+	list := make([]model.Position, 0, len(positions))
+	for i := range positions {
+		list = append(list, fromOKXPosition(positions[i]))
+	}
+	return list, nil
+}
+
+func (m *OKX) AddMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, "add", amount)
+}
+
+// ReduceMargin 减少逐仓保证金
+func (m *OKX) ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	return m.changeMargin(ps, "reduce", amount)
+}
+
+func (m *OKX) FetchBalance(asset string) (*model.Balance, error) {
+	var balances []model.OKXBalance
+	if err := m.request(http.MethodGet, "/api/v5/account/balance", url.Values{"ccy": {asset}}, nil, &balances); err != nil {
+		log.Printf("[%s] Fetch Balance Error: %v", m.Name, err.Error())
+		return nil, err
+	}
+	balance := &model.Balance{Asset: asset}
+	for _, account := range balances {
+		for _, detail := range account.Details {
+			if detail.Ccy == asset {
+				balance.Total = parseFloat(detail.CashBal)
+				balance.Available = parseFloat(detail.AvailBal)
+			}
+		}
+	}
+	return balance, nil
+}
+
+// FetchAccountRisk 查询账户级全仓保证金率，mgnRatio 为权益 / 维持保证金，取倒数与持仓口径一致
+func (m *OKX) FetchAccountRisk(asset string) (*model.AccountRisk, error) {
+	var balances []model.OKXBalance
+	if err := m.request(http.MethodGet, "/api/v5/account/balance", nil, nil, &balances); err != nil {
+		log.Printf("[%s] Fetch Account Error: %v", m.Name, err)
+		return nil, err
+	}
+	if len(balances) == 0 {
+		return nil, fmt.Errorf("[%s] account balance not found", m.Name)
+	}
+	account := balances[0]
+	risk := &model.AccountRisk{
+		Asset:             asset,
+		Equity:            parseFloat(account.AdjEq),
+		InitialMargin:     parseFloat(account.Imr),
+		MaintenanceMargin: parseFloat(account.Mmr),
+	}
+	if risk.Equity == 0 {
+		risk.Equity = parseFloat(account.TotalEq)
+	}
+	for _, detail := range account.Details {
+		if detail.Ccy == asset {
+			risk.Available = parseFloat(detail.AvailBal)
+		}
+	}
+	if mgnRatio := parseFloat(account.MgnRatio); mgnRatio > 0 {
+		risk.MarginRatio = 1 / mgnRatio
+	} else if risk.Equity > 0 {
+		risk.MarginRatio = risk.MaintenanceMargin / risk.Equity
+	}
+	return risk, nil
+}
+
+func (m *OKX) Capabilities() Capabilities {
+	return newCapabilities(CapAddMargin, CapReduceMargin, CapHedgeMode, CapAccountRisk)
+}
+
+func (m *OKX) GetName() string {
+	return m.Name
+}
+
+// changeMargin 调整逐仓保证金，marginType 为 add / reduce，双向持仓时 posSide 为 long / short
+func (m *OKX) changeMargin(ps model.Position, marginType string, amount float64) (*model.MarginResult, error) {
+	symbol := ps.Symbol
+	posSide := "net"
+	if ps.Hedged {
+		posSide = ps.Side
+	}
+	body := map[string]interface{}{
+		"instId":  symbol,
+		"posSide": posSide,
+		"type":    marginType,
+		"amt":     strconv.FormatFloat(amount, 'f', -1, 64),
+	}
+	var data []model.OKXMarginBalance
+	if err := m.request(http.MethodPost, "/api/v5/account/position/margin-balance", nil, body, &data); err != nil {
+		log.Printf("[%s] Change Margin Error (%s): %v", m.Name, marginType, err)
+		return marginFailed(symbol, err)
+	}
+	result := &model.MarginResult{
+		Status: model.MarginStatusSuccess,
+		Symbol: symbol,
+		Amount: amount,
+	}
+	if len(data) > 0 {
+		result.Amount = parseFloat(data[0].Amt)
+	}
+	return result, nil
+}
+
+// request 发送签名请求并解析 data 字段
+func (m *OKX) request(method string, path string, query url.Values, body interface{}, out interface{}) error {
+	requestPath := path
+	if len(query) > 0 {
+		requestPath += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal error: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, m.BaseURL+requestPath, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("OK-ACCESS-KEY", m.Key)
+	req.Header.Set("OK-ACCESS-SIGN", m.sign(timestamp+method+requestPath+string(payload)))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", m.Passphrase)
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var result okxResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse response (status %d): %v", resp.StatusCode, err)
+	}
+	if result.Code != "0" {
+		return &APIError{Exchange: "OKX", Code: result.Code, Msg: result.Msg}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+func (m *OKX) sign(message string) string {
+	mac := hmac.New(sha256.New, []byte(m.Secret))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// fromOKXPosition 将 OKX 原始持仓转换为 model.Position
+func fromOKXPosition(ps model.OKXPosition) model.Position {
+	size := parseFloat(ps.Pos)
+	position := model.Position{
+		Symbol:            ps.InstId,
+		Side:              ps.PosSide,
+		Size:              math.Abs(size),
+		EntryPrice:        parseFloat(ps.AvgPx),
+		MarkPrice:         parseFloat(ps.MarkPx),
+		LiquidationPrice:  parseFloat(ps.LiqPx),
+		MarginMode:        ps.MgnMode,
+		MarginAsset:       ps.Ccy,
+		InitialMargin:     parseFloat(ps.Imr),
+		MaintenanceMargin: parseFloat(ps.Mmr),
+		Leverage:          parseFloat(ps.Lever),
+		UnrealizedPnl:     parseFloat(ps.Upl),
+		Hedged:            ps.PosSide != "net",
+	}
+	// 单向持仓模式下 posSide 为 net，方向由持仓数量的正负决定
+	if ps.PosSide == "net" {
+		position.Side = model.SideLong
+		if size < 0 {
+			position.Side = model.SideShort
+		}
+	}
+	// 逐仓的 imr 为空，使用仓位保证金
+	if position.InitialMargin == 0 {
+		position.InitialMargin = parseFloat(ps.Margin)
+	}
+	// OKX 的 mgnRatio = 权益 / 维持保证金，取倒数与其他交易所口径一致
+	if mgnRatio := parseFloat(ps.MgnRatio); mgnRatio > 0 {
+		position.MarginRatio = 1 / mgnRatio
+	}
+	return position
+}
@@ -0,0 +1,162 @@
+package exchange
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"margin_monitor/model"
+	"math"
+	"net/http"
+	"testing"
+)
+
+const okxTestPositions = `{"code":"0","msg":"","data":[
+	{"instId":"BTC-USDT-SWAP","mgnMode":"isolated","posSide":"net","pos":"-2","avgPx":"60000","markPx":"61000","liqPx":"65000","lever":"10","imr":"","margin":"1200","mmr":"48.8","mgnRatio":"25","upl":"-20","ccy":"USDT"},
+	{"instId":"ETH-USDT-SWAP","mgnMode":"cross","posSide":"long","pos":"3","avgPx":"3000","markPx":"3100","liqPx":"2500","lever":"5","imr":"1860","margin":"","mmr":"37.2","mgnRatio":"","upl":"300","ccy":"USDT"}
+]}`
+
+// newOKXTest 创建指向模拟服务器的 OKX 实例
+func newOKXTest(t *testing.T, responses map[string]string) (*OKX, *mockServer) {
+	t.Helper()
+	srv := newMockServer(t, byPath(responses))
+	return &OKX{
+		BaseURL:    srv.URL,
+		Key:        "key",
+		Secret:     "secret",
+		Passphrase: "passphrase",
+		Client:     srv.Client(),
+	}, srv
+}
+
+// checkOKXSignature 签名 = base64(hmac_sha256(timestamp + method + requestPath + body))
+func checkOKXSignature(t *testing.T, req recordedRequest) {
+	t.Helper()
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(req.Header.Get("OK-ACCESS-TIMESTAMP") + req.Method + req.Path + string(req.Body)))
+	if got, want := req.Header.Get("OK-ACCESS-SIGN"), base64.StdEncoding.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("sign %s, want %s", got, want)
+	}
+	if req.Header.Get("OK-ACCESS-KEY") != "key" || req.Header.Get("OK-ACCESS-PASSPHRASE") != "passphrase" {
+		t.Errorf("auth headers %v", req.Header)
+	}
+}
+
+func TestOKXFetchPositions(t *testing.T) {
+	m, srv := newOKXTest(t, map[string]string{"/api/v5/account/positions": okxTestPositions})
+	positions, err := m.FetchPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("expected 2 positions, got %d", len(positions))
+	}
+
+	// 单向持仓：方向由 pos 正负决定，逐仓 imr 为空时取 margin，mgnRatio 取倒数
+	net := positions[0]
+	if net.Side != model.SideShort || net.Hedged || net.Size != 2 || net.MarginMode != model.MarginModeIsolated || net.InitialMargin != 1200 {
+		t.Errorf("net position %+v", net)
+	}
+	if math.Abs(net.MarginRatio-0.04) > 1e-9 {
+		t.Errorf("net position: margin ratio %v, want 0.04", net.MarginRatio)
+	}
+
+	// 双向持仓：方向取 posSide，mgnRatio 为空时保证金率为 0
+	hedged := positions[1]
+	if hedged.Side != model.SideLong || !hedged.Hedged || hedged.Size != 3 || hedged.MarginMode != model.MarginModeCross || hedged.InitialMargin != 1860 || hedged.MarginRatio != 0 {
+		t.Errorf("hedged position %+v", hedged)
+	}
+
+	req := srv.last(t)
+	if req.Path != "/api/v5/account/positions?instType=SWAP" {
+		t.Errorf("request path %s", req.Path)
+	}
+	checkOKXSignature(t, req)
+}
+
+func TestOKXFetchAccountRisk(t *testing.T) {
+	m, _ := newOKXTest(t, map[string]string{
+		"/api/v5/account/balance": `{"code":"0","msg":"","data":[
+			{"totalEq":"10000","adjEq":"9000","imr":"3000","mmr":"450","mgnRatio":"20","details":[{"ccy":"USDT","availBal":"5000"}]}
+		]}`,
+	})
+	risk, err := m.FetchAccountRisk("USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 账户 mgnRatio 同样取倒数
+	if math.Abs(risk.MarginRatio-0.05) > 1e-9 || risk.Equity != 9000 || risk.Available != 5000 {
+		t.Errorf("risk %+v", risk)
+	}
+}
+
+func TestOKXChangeMargin(t *testing.T) {
+	tests := []struct {
+		name     string
+		position model.Position
+		reduce   bool
+		body     map[string]string
+	}{
+		{
+			name:     "add net",
+			position: model.Position{Symbol: "BTC-USDT-SWAP", Side: model.SideShort},
+			body:     map[string]string{"instId": "BTC-USDT-SWAP", "posSide": "net", "type": "add", "amt": "12.5"},
+		},
+		{
+			name:     "reduce hedged",
+			position: model.Position{Symbol: "ETH-USDT-SWAP", Side: model.SideLong, Hedged: true},
+			reduce:   true,
+			body:     map[string]string{"instId": "ETH-USDT-SWAP", "posSide": "long", "type": "reduce", "amt": "12.5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, srv := newOKXTest(t, map[string]string{
+				"/api/v5/account/position/margin-balance": `{"code":"0","msg":"","data":[{"instId":"x","amt":"12.5"}]}`,
+			})
+			var result *model.MarginResult
+			var err error
+			if tt.reduce {
+				result, err = m.ReduceMargin(tt.position, 12.5)
+			} else {
+				result, err = m.AddMargin(tt.position, 12.5)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Status != model.MarginStatusSuccess || result.Amount != 12.5 {
+				t.Errorf("result %+v", result)
+			}
+
+			req := srv.last(t)
+			var body map[string]string
+			if err := json.Unmarshal(req.Body, &body); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.body {
+				if body[k] != v {
+					t.Errorf("body %s = %q, want %q", k, body[k], v)
+				}
+			}
+			if req.Method != http.MethodPost {
+				t.Errorf("method %s", req.Method)
+			}
+			checkOKXSignature(t, req)
+		})
+	}
+}
+
+func TestOKXErrorCode(t *testing.T) {
+	m, _ := newOKXTest(t, map[string]string{
+		"/api/v5/account/position/margin-balance": `{"code":"51008","msg":"Insufficient balance","data":[]}`,
+	})
+	result, err := m.AddMargin(model.Position{Symbol: "BTC-USDT-SWAP"}, 10)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "51008" {
+		t.Fatalf("expected APIError 51008, got %v", err)
+	}
+	if result.Status != model.MarginStatusFailed || result.Code != "51008" {
+		t.Errorf("result %+v", result)
+	}
+}
@@ -0,0 +1,23 @@
+package exchange
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// floorToStep 数量按交易所步长（如 "0.001"）向下取整，同时返回按步长精度格式化的下单数量；
+// 步长为空或无效时不取整
+func floorToStep(qty float64, step string) (float64, string) {
+	size := parseFloat(step)
+	if size <= 0 {
+		return qty, strconv.FormatFloat(qty, 'f', -1, 64)
+	}
+	decimals := 0
+	if _, fraction, ok := strings.Cut(step, "."); ok {
+		decimals = len(strings.TrimRight(fraction, "0"))
+	}
+	// 加少量余量，避免 0.3 / 0.1 = 2.9999999 这类浮点误差少取一档
+	formatted := strconv.FormatFloat(math.Floor(qty/size+1e-9)*size, 'f', decimals, 64)
+	return parseFloat(formatted), formatted
+}
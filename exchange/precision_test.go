@@ -0,0 +1,61 @@
+package exchange
+
+import "testing"
+
+func TestFloorToStep(t *testing.T) {
+	tests := []struct {
+		name      string
+		qty       float64
+		step      string
+		want      float64
+		formatted string
+	}{
+		{"exact multiple with float error", 0.3, "0.1", 0.3, "0.3"},
+		{"rounds down", 1.23456, "0.001", 1.234, "1.234"},
+		{"trailing zeros in step", 1.23456, "0.0010", 1.234, "1.234"},
+		{"below step", 0.0004, "0.001", 0, "0.000"},
+		{"whole contracts", 7.9, "1", 7, "7"},
+		{"step above one", 27, "5", 25, "25"},
+		{"empty step keeps size", 0.123, "", 0.123, "0.123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, formatted := floorToStep(tt.qty, tt.step)
+			if got != tt.want || formatted != tt.formatted {
+				t.Errorf("floorToStep(%v, %q) = %v %q, want %v %q", tt.qty, tt.step, got, formatted, tt.want, tt.formatted)
+			}
+		})
+	}
+}
+
+func TestBinanceQtyStep(t *testing.T) {
+	srv := newMockServer(t, byPath(map[string]string{
+		"/fapi/v1/exchangeInfo": `{"symbols":[{"symbol":"BTCUSDT","filters":[
+			{"filterType":"PRICE_FILTER","tickSize":"0.10"},
+			{"filterType":"LOT_SIZE","stepSize":"0.001"},
+			{"filterType":"MARKET_LOT_SIZE","stepSize":"0.010"}
+		]}]}`,
+	}))
+	m := &Binance{Name: "Binance", FapiURL: srv.URL, Client: srv.Client()}
+
+	// 市价单优先使用 MARKET_LOT_SIZE
+	step, err := m.qtyStep("BTC/USDT:USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step != "0.010" {
+		t.Errorf("step %s, want 0.010", step)
+	}
+	if req := srv.last(t); req.Path != "/fapi/v1/exchangeInfo?symbol=BTCUSDT" {
+		t.Errorf("request path %s", req.Path)
+	}
+	if size, _ := floorToStep(0.0567, step); size != 0.05 {
+		t.Errorf("rounded size %v, want 0.05", size)
+	}
+
+	// 第二次读取缓存
+	srv.Close()
+	if step, err := m.qtyStep("BTC/USDT:USDT"); err != nil || step != "0.010" {
+		t.Errorf("cached step %s, %v", step, err)
+	}
+}
@@ -0,0 +1,199 @@
+package exchange
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Priority 请求优先级，保证金操作优先于持仓轮询
+type Priority int
+
+const (
+	PriorityPoll Priority = iota
+	PriorityMargin
+)
+
+// waitingRetry 有保证金操作排队时轮询请求的重试间隔
+const waitingRetry = 50 * time.Millisecond
+
+type priorityKey struct{}
+
+// withPriority 在 context 中标记请求优先级，供限流 Transport 读取
+func withPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityPoll
+}
+
+// RateLimiter 按交易所权重窗口限流，每个交易所实例一个
+// 轮询请求不能占用 Reserved 部分的权重，且有保证金操作排队时让行
+type RateLimiter struct {
+	Name     string
+	Capacity int           // 窗口内允许的总权重
+	Reserved int           // 为保证金操作预留的权重
+	Window   time.Duration // 权重窗口
+
+	mu          sync.Mutex
+	start       time.Time // 当前窗口开始时间
+	used        int       // 当前窗口已用权重（本地计数与交易所返回取较大值）
+	waiting     int       // 排队中的保证金操作数
+	pollPaused  time.Time // 在此之前暂停轮询请求
+	allPaused   time.Time // 在此之前暂停所有请求（429 / 418）
+	lastWarning time.Time
+}
+
+func NewRateLimiter(name string, capacity int, reserved int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		Name:     name,
+		Capacity: capacity,
+		Reserved: reserved,
+		Window:   window,
+	}
+}
+
+// Wait 阻塞直到可以发送权重为 weight 的请求，ctx 结束时返回错误
+func (l *RateLimiter) Wait(ctx context.Context, priority Priority, weight int) error {
+	if priority == PriorityMargin {
+		l.mu.Lock()
+		l.waiting++
+		l.mu.Unlock()
+		defer func() {
+			l.mu.Lock()
+			l.waiting--
+			l.mu.Unlock()
+		}()
+	}
+
+	for {
+		delay := l.take(priority, weight)
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take 尝试占用权重，返回需要等待的时间，0 表示已占用
+func (l *RateLimiter) take(priority Priority, weight int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.roll(now)
+	if now.Before(l.allPaused) {
+		return l.allPaused.Sub(now)
+	}
+
+	limit := l.Capacity
+	if priority == PriorityPoll {
+		if now.Before(l.pollPaused) {
+			return l.pollPaused.Sub(now)
+		}
+		if l.waiting > 0 {
+			return waitingRetry
+		}
+		limit -= l.Reserved
+	}
+	if l.used+weight > limit {
+		delay := l.start.Add(l.Window).Sub(now)
+		if now.Sub(l.lastWarning) >= l.Window {
+			l.lastWarning = now
+			log.Printf("⏳ [%s] rate limit weight %d/%d used, waiting %s", l.Name, l.used, l.Capacity, delay.Round(time.Millisecond))
+		}
+		return delay
+	}
+	l.used += weight
+	return 0
+}
+
+// roll 进入新窗口时清零已用权重
+func (l *RateLimiter) roll(now time.Time) {
+	if now.Sub(l.start) >= l.Window {
+		l.start = now.Truncate(l.Window)
+		l.used = 0
+	}
+}
+
+// Update 使用交易所返回的当前窗口已用权重校正本地计数
+func (l *RateLimiter) Update(used int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.roll(time.Now())
+	if used > l.used {
+		l.used = used
+	}
+}
+
+// PausePolling 在 until 之前暂停轮询请求，保证金操作仍可使用剩余额度
+func (l *RateLimiter) PausePolling(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.pollPaused) {
+		l.pollPaused = until
+	}
+}
+
+// Pause 在 until 之前暂停所有请求，用于已被交易所限流或封禁时
+func (l *RateLimiter) Pause(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.allPaused) {
+		l.allPaused = until
+		log.Printf("⛔ [%s] rate limited by exchange, pausing requests until %s", l.Name, until.Format(time.TimeOnly))
+	}
+}
+
+// limitedTransport 发送请求前按限流器等待（每个请求权重 1），收到响应后读取交易所的限流响应头
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+	observe func(limiter *RateLimiter, resp *http.Response)
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), priorityFrom(req.Context()), 1); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.observe(t.limiter, resp)
+	}
+	return resp, err
+}
+
+// limitClient 返回经过限流器的 http.Client 副本
+func limitClient(client *http.Client, limiter *RateLimiter, observe func(limiter *RateLimiter, resp *http.Response)) *http.Client {
+	limited := http.Client{}
+	if client != nil {
+		limited = *client
+	}
+	base := limited.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	limited.Transport = &limitedTransport{base: base, limiter: limiter, observe: observe}
+	return &limited
+}
+
+// retryAfter 解析 Retry-After 响应头（秒），缺省时返回 fallback
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
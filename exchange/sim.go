@@ -0,0 +1,351 @@
+package exchange
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/model"
+	"math"
+	"os"
+	"sync"
+)
+
+// SimScenario 模拟盘场景，YAML 或 JSON 均可（JSON 是 YAML 的子集）
+type SimScenario struct {
+	Name      string               `yaml:"name"`
+	Balance   float64              `yaml:"balance"` // 合约钱包 USDT 余额
+	Wallets   map[string]float64   `yaml:"wallets"` // spot / funding 钱包 USDT 余额
+	Positions []SimPosition        `yaml:"positions"`
+	Prices    map[string][]float64 `yaml:"prices"` // 每个交易对的标记价格路径，每次拉取持仓前进一步
+}
+
+// SimPosition 模拟逐仓持仓
+type SimPosition struct {
+	Symbol                string  `yaml:"symbol"`
+	Side                  string  `yaml:"side"` // long / short
+	Size                  float64 `yaml:"size"`
+	EntryPrice            float64 `yaml:"entryPrice"`
+	Margin                float64 `yaml:"margin"`
+	Leverage              float64 `yaml:"leverage"`
+	MaintenanceMarginRate float64 `yaml:"maintenanceMarginRate"`
+	AutoAddMargin         bool    `yaml:"autoAddMargin"`
+	MarginMode            string  `yaml:"marginMode"` // isolated（默认）/ cross，全仓持仓共用钱包余额
+}
+
+// Sim 根据场景文件驱动的模拟交易所，用于离线验证追加保证金逻辑
+type Sim struct {
+	Name      string
+	Scenario  SimScenario
+	mu        sync.Mutex
+	tick      int
+	positions []SimPosition
+	balance   float64
+	wallets   map[string]float64
+}
+
+func NewSim(conf config.Exchange) (Exchange, error) {
+	path := conf.Scenario
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scenario SimScenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	positions := make([]SimPosition, len(scenario.Positions))
+	copy(positions, scenario.Positions)
+	wallets := make(map[string]float64, len(scenario.Wallets))
+	for wallet, balance := range scenario.Wallets {
+		wallets[wallet] = balance
+	}
+	name := accountName("Sim", conf)
+	log.Printf("[%s] scenario %q loaded: %d positions", name, scenario.Name, len(positions))
+	return &Sim{
+		Name:      name,
+		Scenario:  scenario,
+		tick:      -1,
+		positions: positions,
+		balance:   scenario.Balance,
+		wallets:   wallets,
+	}, nil
+}
+
+// FetchPositions 价格前进一步并返回当前持仓，维持保证金不足的持仓视为被强平
+func (m *Sim) FetchPositions() ([]model.Position, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tick++
+	// 全仓持仓共用账户权益，权益不足以覆盖维持保证金时全部强平
+	account := m.accountRisk()
+	crossLiquidated := account.MaintenanceMargin > 0 && account.MaintenanceMargin >= account.Equity
+	if crossLiquidated {
+		log.Printf("💥 [%s] tick %d: cross account liquidated, equity %.2f, maintenance margin %.2f", m.Name, m.tick, account.Equity, account.MaintenanceMargin)
+		m.balance = 0
+	}
+
+	list := make([]model.Position, 0, len(m.positions))
+	alive := m.positions[:0]
+	for _, ps := range m.positions {
+		position := m.toPosition(ps)
+		if ps.MarginMode == model.MarginModeCross {
+			if crossLiquidated {
+				continue
+			}
+			position.MarginRatio = account.MarginRatio
+			position.LiquidationPrice = 0
+		} else if position.MaintenanceMargin >= ps.Margin+position.UnrealizedPnl {
+			log.Printf("💥 [%s] tick %d: %s liquidated at %.4f", m.Name, m.tick, ps.Symbol, position.MarkPrice)
+			continue
+		}
+		alive = append(alive, ps)
+		list = append(list, position)
+	}
+	m.positions = alive
+	return list, nil
+}
+
+// FetchAccountRisk 全仓账户风险: 权益 = 钱包余额 + 全仓持仓保证金与未实现盈亏
+func (m *Sim) FetchAccountRisk(asset string) (*model.AccountRisk, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	risk := m.accountRisk()
+	risk.Asset = asset
+	return &risk, nil
+}
+
+func (m *Sim) accountRisk() model.AccountRisk {
+	risk := model.AccountRisk{Equity: m.balance, Available: m.balance}
+	for _, ps := range m.positions {
+		if ps.MarginMode != model.MarginModeCross {
+			continue
+		}
+		position := m.toPosition(ps)
+		risk.Equity += ps.Margin + position.UnrealizedPnl
+		risk.InitialMargin += position.InitialMargin
+		risk.MaintenanceMargin += position.MaintenanceMargin
+	}
+	if risk.Equity > 0 {
+		risk.MarginRatio = risk.MaintenanceMargin / risk.Equity
+	}
+	return risk
+}
+
+// ReducePosition 按当前标记价格减仓，释放对应比例的保证金并实现盈亏
+func (m *Sim) ReducePosition(ps model.Position, size float64) (*model.MarginResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.positions {
+		position := &m.positions[i]
+		if position.Symbol != ps.Symbol || position.Side != ps.Side {
+			continue
+		}
+		size = math.Min(size, position.Size)
+		current := m.toPosition(*position)
+		fraction := size / position.Size
+		released := position.Margin*fraction + current.UnrealizedPnl*fraction
+		position.Margin -= position.Margin * fraction
+		position.Size -= size
+		m.balance += released
+		log.Printf("[%s] tick %d: %s %s reduced by %.6f at %.4f, released %.2f", m.Name, m.tick, ps.Symbol, ps.Side, size, current.MarkPrice, released)
+		return &model.MarginResult{
+			Status: model.MarginStatusSuccess,
+			Symbol: ps.Symbol,
+			Amount: size,
+			TxID:   fmt.Sprintf("sim-reduce-%d", m.tick),
+		}, nil
+	}
+	return marginFailed(ps.Symbol, fmt.Errorf("[%s] position %s not found", m.Name, ps.Symbol))
+}
+
+func (m *Sim) AddMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	symbol := ps.Symbol
+	for i := range m.positions {
+		// 同一交易对可以同时有多空两个仓位，按方向匹配
+		if m.positions[i].Symbol != symbol || m.positions[i].Side != ps.Side {
+			continue
+		}
+		if m.positions[i].MarginMode == model.MarginModeCross {
+			return marginFailed(symbol, fmt.Errorf("[%s] %s is a cross position", m.Name, symbol))
+		}
+		if amount > m.balance {
+			return marginFailed(symbol, fmt.Errorf("[%s] insufficient balance: need %.2f, available %.2f", m.Name, amount, m.balance))
+		}
+		m.balance -= amount
+		m.positions[i].Margin += amount
+		log.Printf("[%s] tick %d: %s margin +%.2f, now %.2f", m.Name, m.tick, symbol, amount, m.positions[i].Margin)
+		return &model.MarginResult{
+			Status:    model.MarginStatusSuccess,
+			Symbol:    symbol,
+			Amount:    amount,
+			NewMargin: m.positions[i].Margin,
+			TxID:      fmt.Sprintf("sim-%d", m.tick),
+		}, nil
+	}
+	return marginFailed(symbol, fmt.Errorf("[%s] position %s not found", m.Name, symbol))
+}
+
+// ReduceMargin 减少逐仓保证金，减少后保证金不能低于初始保证金
+func (m *Sim) ReduceMargin(ps model.Position, amount float64) (*model.MarginResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	symbol := ps.Symbol
+	for i := range m.positions {
+		position := &m.positions[i]
+		if position.Symbol != symbol || position.Side != ps.Side {
+			continue
+		}
+		current := m.toPosition(*position)
+		if removable := position.Margin - current.InitialMargin; amount > removable {
+			return marginFailed(symbol, fmt.Errorf("[%s] cannot reduce %.2f, removable %.2f", m.Name, amount, removable))
+		}
+		position.Margin -= amount
+		m.balance += amount
+		log.Printf("[%s] tick %d: %s margin -%.2f, now %.2f", m.Name, m.tick, symbol, amount, position.Margin)
+		return &model.MarginResult{
+			Status:    model.MarginStatusSuccess,
+			Symbol:    symbol,
+			Amount:    amount,
+			NewMargin: position.Margin,
+			TxID:      fmt.Sprintf("sim-%d", m.tick),
+		}, nil
+	}
+	return marginFailed(symbol, fmt.Errorf("[%s] position %s not found", m.Name, symbol))
+}
+
+func (m *Sim) FetchBalance(asset string) (*model.Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	total := m.balance
+	for _, ps := range m.positions {
+		total += ps.Margin
+	}
+	return &model.Balance{
+		Asset:     asset,
+		Total:     total,
+		Available: m.balance,
+	}, nil
+}
+
+func (m *Sim) FundingWallets() []string {
+	return []string{WalletSpot, WalletFunding}
+}
+
+func (m *Sim) FetchWalletBalance(wallet string, asset string) (*model.Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &model.Balance{Asset: asset, Total: m.wallets[wallet], Available: m.wallets[wallet]}, nil
+}
+
+func (m *Sim) TransferToFutures(wallet string, asset string, amount float64) (*model.TransferResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if amount > m.wallets[wallet] {
+		return nil, fmt.Errorf("[%s] insufficient %s balance: need %.2f, available %.2f", m.Name, wallet, amount, m.wallets[wallet])
+	}
+	m.wallets[wallet] -= amount
+	m.balance += amount
+	log.Printf("[%s] tick %d: transferred %.2f %s from %s to futures", m.Name, m.tick, amount, asset, wallet)
+	return &model.TransferResult{
+		TxID:   fmt.Sprintf("sim-transfer-%d", m.tick),
+		Asset:  asset,
+		Amount: amount,
+		From:   wallet,
+		To:     WalletFutures,
+	}, nil
+}
+
+// FetchCandles 由价格路径生成 K 线，每个 tick 一根（忽略 interval），开盘为上一 tick 价格
+func (m *Sim) FetchCandles(symbol string, interval string, limit int) ([]model.Candle, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path := m.Scenario.Prices[symbol]
+	end := m.tick
+	if end >= len(path) {
+		end = len(path) - 1
+	}
+	start := end - limit + 1
+	if start < 0 {
+		start = 0
+	}
+	candles := make([]model.Candle, 0, limit)
+	for i := start; i <= end; i++ {
+		open := path[i]
+		if i > 0 {
+			open = path[i-1]
+		}
+		candles = append(candles, model.Candle{
+			Time:  int64(i),
+			Open:  open,
+			High:  math.Max(open, path[i]),
+			Low:   math.Min(open, path[i]),
+			Close: path[i],
+		})
+	}
+	return candles, nil
+}
+
+func (m *Sim) Capabilities() Capabilities {
+	return newCapabilities(CapAddMargin, CapReduceMargin, CapHedgeMode, CapTransfer, CapAccountRisk, CapReducePosition, CapCandles)
+}
+
+func (m *Sim) GetName() string {
+	return m.Name
+}
+
+// markPrice 返回当前 tick 的标记价格，路径走完后保持最后一个价格
+func (m *Sim) markPrice(ps SimPosition) float64 {
+	path := m.Scenario.Prices[ps.Symbol]
+	if len(path) == 0 {
+		return ps.EntryPrice
+	}
+	if m.tick >= len(path) {
+		return path[len(path)-1]
+	}
+	return path[m.tick]
+}
+
+func (m *Sim) toPosition(ps SimPosition) model.Position {
+	direction := 1.0
+	if ps.Side == model.SideShort {
+		direction = -1
+	}
+	mark := m.markPrice(ps)
+	marginMode := model.MarginModeIsolated
+	if ps.MarginMode == model.MarginModeCross {
+		marginMode = model.MarginModeCross
+	}
+	position := model.Position{
+		Symbol:            ps.Symbol,
+		Side:              ps.Side,
+		Size:              ps.Size,
+		EntryPrice:        ps.EntryPrice,
+		MarkPrice:         mark,
+		MarginMode:        marginMode,
+		MarginAsset:       "USDT",
+		MaintenanceMargin: mark * ps.Size * ps.MaintenanceMarginRate,
+		Leverage:          ps.Leverage,
+		UnrealizedPnl:     (mark - ps.EntryPrice) * ps.Size * direction,
+		AutoAddMargin:     ps.AutoAddMargin,
+	}
+	if ps.Leverage > 0 {
+		position.InitialMargin = ps.EntryPrice * ps.Size / ps.Leverage
+	}
+	if balance := ps.Margin + position.UnrealizedPnl; balance > 0 {
+		position.MarginRatio = position.MaintenanceMargin / balance
+	}
+	// 强平价: margin + (P - entry) * size * dir = P * size * mmr
+	if denominator := ps.Size * (direction - ps.MaintenanceMarginRate); denominator != 0 {
+		position.LiquidationPrice = (ps.EntryPrice*ps.Size*direction - ps.Margin) / denominator
+	}
+	return position
+}
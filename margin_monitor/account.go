@@ -0,0 +1,142 @@
+package margin_monitor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"margin_monitor/exchange"
+	"margin_monitor/model"
+	"math"
+	"strings"
+	"time"
+)
+
+// defaultCrossReduceInterval 两次减仓的默认最小间隔
+const defaultCrossReduceInterval = time.Minute
+
+// checkAccount 全仓持仓按账户保证金率检查，超过阈值时告警并从现货 / 资金账户划转补充钱包，
+// 划转仍不足时按配置减仓；每轮检查都会重新评估，减仓按比例逐步进行。
+// urgent 为有全仓持仓距强平价过近，此时无论账户保证金率多少都处理
+func (c *Controller) checkAccount(ex exchange.Exchange, cross []model.Position, urgent bool) {
+	asset := cross[0].MarginAsset
+	if asset == "" {
+		asset = "USDT"
+	}
+	// 记录读取时间，减仓后需读到新的账户风险才会再次减仓
+	readAt := time.Now()
+	risk, err := c.accountRisk(ex, asset, cross)
+	if err != nil {
+		log.Printf("%s fetch account risk error: %v\n", ex.GetName(), err)
+		return
+	}
+	log.Printf("Checking account: Exchange=%s, MarginRatio=%.4f, Equity=%.4f, MaintenanceMargin=%.4f\n",
+		ex.GetName(), risk.MarginRatio, risk.Equity, risk.MaintenanceMargin)
+
+	threshold := c.dangerThreshold(ex)
+	if risk.MarginRatio <= threshold && !urgent {
+		return
+	}
+
+	legs := make([]string, 0, len(cross))
+	for _, ps := range cross {
+		legs = append(legs, legName(ps))
+	}
+	reason := fmt.Sprintf("cross account margin ratio %.4f exceeds threshold %.4f", risk.MarginRatio, threshold)
+	if risk.MarginRatio <= threshold {
+		reason = fmt.Sprintf("cross position too close to liquidation, account margin ratio %.4f", risk.MarginRatio)
+	}
+	msg := fmt.Sprintf("🚨 %s: %s, equity %.4f %s, maintenance margin %.4f, positions: %s",
+		ex.GetName(), reason, risk.Equity, asset, risk.MaintenanceMargin, strings.Join(legs, ", "))
+	log.Println(msg)
+	c.M.SendTelegramMessage(msg)
+
+	// 权益补到维持保证金 / 阈值即可回到阈值以下，划转金额按全仓初始保证金乘追加倍数计算
+	shortfall := risk.MaintenanceMargin/threshold - risk.Equity
+	var initialMargin float64
+	for _, ps := range cross {
+		initialMargin += ps.InitialMargin
+	}
+	amount := roundUpAmount(math.Max(initialMargin*c.addMultiple(ex), shortfall), asset)
+	// 紧急处理时 shortfall 可能为负，需实际划转成功才不减仓
+	if transferred := c.fund(ex, asset, amount); transferred > 0 && transferred >= shortfall {
+		return
+	}
+	c.reduceExposure(ex, cross, readAt)
+}
+
+// accountRisk 查询账户级风险，交易所不支持时以全仓持仓中最高的保证金率近似
+func (c *Controller) accountRisk(ex exchange.Exchange, asset string, cross []model.Position) (*model.AccountRisk, error) {
+	if risker, ok := ex.(exchange.AccountRisker); ok && ex.Capabilities().Has(exchange.CapAccountRisk) {
+		var risk *model.AccountRisk
+		err := c.call(ex, "fetch account risk", func() (err error) {
+			risk, err = risker.FetchAccountRisk(asset)
+			return err
+		})
+		return risk, err
+	}
+
+	risk := &model.AccountRisk{Asset: asset}
+	for _, ps := range cross {
+		risk.InitialMargin += ps.InitialMargin
+		risk.MaintenanceMargin += ps.MaintenanceMargin
+		risk.MarginRatio = math.Max(risk.MarginRatio, ps.MarginRatio)
+	}
+	if risk.MarginRatio > 0 {
+		risk.Equity = risk.MaintenanceMargin / risk.MarginRatio
+	}
+	return risk, nil
+}
+
+// reduceExposure 按 Monitor.CrossReduceFraction 减仓保证金占用最大的全仓持仓；
+// 成交与风险刷新有延迟，距上次减仓不足 Monitor.CrossReduceInterval 或 readAt 早于上次减仓时跳过，避免连续减仓
+func (c *Controller) reduceExposure(ex exchange.Exchange, cross []model.Position, readAt time.Time) {
+	fraction := c.Conf.Monitor.CrossReduceFraction
+	if fraction <= 0 {
+		log.Printf("📍 %s: crossReduceFraction not set, alert only\n", ex.GetName())
+		return
+	}
+	// 不支持时只通知一次，需要手动减仓
+	if !c.supports(ex, exchange.CapReducePosition) {
+		return
+	}
+	reducer, ok := ex.(exchange.ExposureReducer)
+	if !ok {
+		return
+	}
+	if last, ok := c.reduced.Load(ex.GetName()); ok {
+		at := last.(time.Time)
+		if time.Since(at) < c.crossReduceInterval() || !readAt.After(at) {
+			log.Printf("📍 %s: reduced position at %s, waiting for refreshed account risk\n",
+				ex.GetName(), at.Format(time.RFC3339))
+			return
+		}
+	}
+
+	largest := cross[0]
+	for _, ps := range cross[1:] {
+		if ps.InitialMargin > largest.InitialMargin {
+			largest = ps
+		}
+	}
+	size := largest.Size * math.Min(fraction, 1)
+	var result *model.MarginResult
+	err := c.callOnce(ex, "reduce position", func() (err error) {
+		result, err = reducer.ReducePosition(largest, size)
+		return err
+	})
+	if errors.Is(err, exchange.ErrBelowQtyStep) {
+		// 仓位太小无法按步长减仓，不计时，等待下一轮检查
+		log.Printf("📍 %s %s: reduce %.8f skipped: %v\n", ex.GetName(), legName(largest), size, err)
+		return
+	}
+	// 失败同样计时，避免每轮检查重复下单
+	c.reduced.Store(ex.GetName(), time.Now())
+	c.M.SendTelegramMessage(fmt.Sprintf("✂️ %s %s: %s", ex.GetName(), legName(largest), marginMessage("Reduce position", result, err)))
+}
+
+func (c *Controller) crossReduceInterval() time.Duration {
+	if c.Conf.Monitor.CrossReduceInterval > 0 {
+		return time.Duration(c.Conf.Monitor.CrossReduceInterval) * time.Second
+	}
+	return defaultCrossReduceInterval
+}
@@ -0,0 +1,73 @@
+package margin_monitor
+
+import (
+	"fmt"
+	"log"
+	"margin_monitor/exchange"
+	"margin_monitor/model"
+	"math"
+)
+
+// fitBalance 按合约钱包可用余额调整追加金额，余额不足时告警并给出缺口
+// 返回 false 表示钱包没有可用余额，本次不追加
+func (c *Controller) fitBalance(ex exchange.Exchange, ps model.Position, amount float64) (float64, bool) {
+	asset := ps.MarginAsset
+	if asset == "" {
+		asset = "USDT"
+	}
+	var balance *model.Balance
+	err := c.call(ex, "fetch balance", func() (err error) {
+		balance, err = ex.FetchBalance(asset)
+		return err
+	})
+	if err != nil {
+		// 查询余额失败时不阻塞追加，交给交易所校验
+		log.Printf("⚠️ %s fetch balance error, adding margin without balance check: %v\n", ex.GetName(), err)
+		return amount, true
+	}
+	if balance.Available >= amount {
+		return amount, true
+	}
+
+	// 开启自动划转时先从现货 / 资金账户补足缺口
+	if transferred := c.fund(ex, asset, amount-balance.Available); transferred > 0 {
+		balance.Available += transferred
+		if balance.Available >= amount {
+			return amount, true
+		}
+	}
+
+	missing := amount - balance.Available
+	available := roundDownAmount(balance.Available, asset)
+	if available <= 0 {
+		msg := fmt.Sprintf("❌ %s %s: wallet cannot cover margin top-up, need %.4f %s, available %.4f, missing %.4f",
+			ex.GetName(), legName(ps), amount, asset, balance.Available, missing)
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+		return 0, false
+	}
+
+	msg := fmt.Sprintf("⚠️ %s %s: wallet short for margin top-up, need %.4f %s, available %.4f, missing %.4f, adding %.4f only",
+		ex.GetName(), legName(ps), amount, asset, balance.Available, missing, available)
+	log.Println(msg)
+	c.M.SendTelegramMessage(msg)
+	return available, true
+}
+
+// roundUpAmount 稳定币保证金向上取整；币本位合约的保证金是标的币，保留 6 位小数向上取整
+func roundUpAmount(amount float64, asset string) float64 {
+	switch asset {
+	case "", "USDT", "USDC", "USD", "BUSD":
+		return math.Ceil(amount)
+	}
+	return math.Ceil(amount*1e6) / 1e6
+}
+
+// roundDownAmount 与 roundUpAmount 对应，向下取整避免超出可用余额
+func roundDownAmount(amount float64, asset string) float64 {
+	switch asset {
+	case "", "USDT", "USDC", "USD", "BUSD":
+		return math.Floor(amount)
+	}
+	return math.Floor(amount*1e6) / 1e6
+}
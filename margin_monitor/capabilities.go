@@ -0,0 +1,48 @@
+package margin_monitor
+
+import (
+	"fmt"
+	"log"
+	"margin_monitor/exchange"
+	"strings"
+)
+
+// supports 检查交易所是否支持某项功能，不支持时说明原因，每个交易所每项功能只通知一次
+func (c *Controller) supports(ex exchange.Exchange, capability exchange.Capability) bool {
+	caps := ex.Capabilities()
+	if caps.Has(capability) {
+		return true
+	}
+	key := ex.GetName() + "|" + string(capability)
+	if _, loaded := c.skipped.LoadOrStore(key, struct{}{}); !loaded {
+		msg := fmt.Sprintf("⏭️ %s: %s skipped: %s", ex.GetName(), capability, caps.Reason(capability))
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+	}
+	return false
+}
+
+// reportCapabilities 启动时输出每个交易所支持的功能
+func (c *Controller) reportCapabilities() {
+	lines := make([]string, 0, len(c.M.Exchange)+1)
+	lines = append(lines, "📋 Exchange capabilities:")
+	for _, ex := range c.M.Exchange {
+		caps := ex.Capabilities()
+		var supported, unsupported []string
+		for _, capability := range exchange.AllCapabilities {
+			if caps.Has(capability) {
+				supported = append(supported, string(capability))
+			} else {
+				unsupported = append(unsupported, fmt.Sprintf("%s (%s)", capability, caps.Reason(capability)))
+			}
+		}
+		line := fmt.Sprintf("%s: ✅ %s", ex.GetName(), strings.Join(supported, ", "))
+		if len(unsupported) > 0 {
+			line += fmt.Sprintf("; ❌ %s", strings.Join(unsupported, ", "))
+		}
+		lines = append(lines, line)
+	}
+	msg := strings.Join(lines, "\n")
+	log.Println(msg)
+	c.M.SendTelegramMessage(msg)
+}
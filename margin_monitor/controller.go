@@ -2,13 +2,13 @@ package margin_monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	ccxt "github.com/ccxt/ccxt/go/v4"
 	"log"
 	"margin_monitor/config"
 	"margin_monitor/exchange"
 	"margin_monitor/model"
-	"math"
+	"sync"
 	"time"
 )
 
@@ -18,17 +18,43 @@ func NewController(conf *config.Config) (*Controller, error) {
 		log.Fatalf("init monitor err: %v", err)
 	}
 
-	return &Controller{
+	controller := &Controller{
 		Conf: conf,
 		M:    m,
-		Pair: NewPair(conf),
-	}, nil
+	}
+	if err := controller.validateReclaim(); err != nil {
+		return nil, err
+	}
+	// 未开启交易对刷新时不连接 Redis
+	if conf.RefreshPairs.Interval > 0 {
+		controller.Pair = NewPair(conf)
+	}
+	return controller, nil
 }
 
 type Controller struct {
 	Conf *config.Config
 	M    *Monitor
 	Pair *Pair
+
+	// adding 记录正在追加保证金的持仓，避免轮询与推送同时触发重复追加
+	adding sync.Map
+	// polling 记录正在检查的交易所，轮询与推送触发的拉取共用
+	polling sync.Map
+	// breakers 每个交易所的熔断器
+	breakers sync.Map
+	// calm 记录持仓开始低于安全阈值的时间，用于回收保证金
+	calm sync.Map
+	// skipped 已通知过的不支持功能
+	skipped sync.Map
+	// liquidationAlerts 每个持仓最近一次强平距离告警
+	liquidationAlerts sync.Map
+	// atrs 交易对 ATR 缓存
+	atrs sync.Map
+	// reduced 每个账户最近一次减仓完成的时间
+	reduced sync.Map
+	// funding 自动划转的每日额度
+	funding fundingQuota
 }
 
 func (c *Controller) Start(ctx context.Context) error {
@@ -51,6 +77,9 @@ func (c *Controller) Start(ctx context.Context) error {
 		defer checkTicker.Stop()
 	}
 
+	c.reportCapabilities()
+	c.startStreams(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -84,55 +113,247 @@ func checkTickerC(t *time.Ticker) <-chan time.Time {
 func (c *Controller) checkExchanges() {
 	for i := range c.M.Exchange {
 		ex := c.M.Exchange[i]
+		// 上一轮仍在限流等待时跳过，避免请求堆积
+		if _, loaded := c.polling.LoadOrStore(ex, struct{}{}); loaded {
+			log.Printf("📍 %s: previous check still running, skip\n", ex.GetName())
+			continue
+		}
 		go func() {
-			positions, err := ex.FetchPositions()
-			if err != nil {
-				log.Printf("fetch positions error: %v\n", err)
-				c.M.SendTelegramMessage(fmt.Sprintf("%s: fetch positions error", ex.GetName()))
-				return
-			}
-			c.handlePositions(ex, positions)
+			defer c.polling.Delete(ex)
+			c.checkExchange(ex)
 		}()
 	}
 }
 
-// handlePositions 检查每个持仓是否超出风险阈值
-func (c *Controller) handlePositions(exchange exchange.Exchange, positions interface{}) {
-	switch exchange.GetName() {
-	case "Binance":
-		if binancePositions, ok := positions.([]ccxt.Position); ok {
-			for i := range binancePositions {
-				ps := binancePositions[i]
-				log.Printf("Checking position: Symbol=%s, MarginRatio=%.4f, InitialMargin=%.4f\n",
-					*ps.Symbol, *ps.MarginRatio, *ps.InitialMargin)
+// checkExchange 拉取单个交易所持仓并检查
+func (c *Controller) checkExchange(ex exchange.Exchange) {
+	var positions []model.Position
+	err := c.call(ex, "fetch positions", func() (err error) {
+		positions, err = ex.FetchPositions()
+		return err
+	})
+	if err != nil {
+		// 失败告警由熔断器统一发送，避免每轮重复通知
+		log.Printf("%s fetch positions error: %v\n", ex.GetName(), err)
+		return
+	}
+	c.handlePositions(ex, positions)
+	c.pruneCalm(ex, positions)
+}
 
-				if *ps.MarginRatio > c.Conf.Monitor.DangerThreshold {
-					addAmount := math.Ceil(*ps.InitialMargin * c.Conf.AddMultiple)
-					log.Printf("⚠️ Margin ratio exceeds threshold! Adding margin: Symbol=%s, Amount=%.4f\n",
-						*ps.Symbol, addAmount)
+// startStreams 为支持推送的交易所订阅持仓变化，轮询仍作为兜底对账
+func (c *Controller) startStreams(ctx context.Context) {
+	for i := range c.M.Exchange {
+		ex := c.M.Exchange[i]
+		if !ex.Capabilities().Has(exchange.CapStream) {
+			continue
+		}
+		streamer, ok := ex.(exchange.Streamer)
+		if !ok {
+			continue
+		}
+
+		events := make(chan model.PositionEvent, 16)
+		go func() {
+			if err := streamer.Subscribe(ctx, events); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("%s position stream stopped: %v\n", ex.GetName(), err)
+				c.M.SendTelegramMessage(fmt.Sprintf("%s: position stream stopped: %v", ex.GetName(), err))
+			}
+		}()
+		go c.handleEvents(ctx, ex, events)
+	}
+}
 
-					go func(symbol string, amount float64) {
-						msg := exchange.AddMargin(symbol, amount)
-						c.M.SendTelegramMessage(msg)
-					}(*ps.Symbol, addAmount)
+// handleEvents 处理推送事件，短时间内的连续推送合并为一次拉取
+func (c *Controller) handleEvents(ctx context.Context, ex exchange.Exchange, events <-chan model.PositionEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			refresh := c.handleEvent(ex, event)
+			for drained := false; !drained; {
+				select {
+				case event = <-events:
+					refresh = c.handleEvent(ex, event) || refresh
+				default:
+					drained = true
 				}
 			}
+			if refresh {
+				c.refreshExchange(ex)
+			}
+		}
+	}
+}
+
+// refreshExchange 推送触发的重新拉取，与轮询共用同一检查标记；正在检查时跳过，由该轮检查对账
+func (c *Controller) refreshExchange(ex exchange.Exchange) {
+	if _, loaded := c.polling.LoadOrStore(ex, struct{}{}); loaded {
+		log.Printf("📍 %s: check already running, skip stream refresh\n", ex.GetName())
+		return
+	}
+	defer c.polling.Delete(ex)
+	c.checkExchange(ex)
+}
+
+// handleEvent 直接检查推送中带有的持仓，返回是否需要重新拉取
+func (c *Controller) handleEvent(ex exchange.Exchange, event model.PositionEvent) bool {
+	log.Printf("📡 %s position event: %s\n", ex.GetName(), event.Reason)
+	if len(event.Positions) == 0 {
+		return true
+	}
+	c.handlePositions(ex, event.Positions)
+	return false
+}
+
+// handlePositions 检查每个持仓是否超出风险阈值，全仓持仓按账户整体检查
+func (c *Controller) handlePositions(ex exchange.Exchange, positions []model.Position) {
+	var cross []model.Position
+	urgent := false
+	for i := range positions {
+		ps := positions[i]
+		log.Printf("Checking position: Exchange=%s, Symbol=%s, Mode=%s, MarginRatio=%.4f, InitialMargin=%.4f\n",
+			ex.GetName(), legName(ps), ps.MarginMode, ps.MarginRatio, ps.InitialMargin)
+		// 距强平价过近时无论保证金率多少都立即处理
+		level := c.checkLiquidation(ex, ps)
+
+		// 全仓持仓没有独立保证金，追加保证金无意义
+		if ps.MarginMode == model.MarginModeCross {
+			cross = append(cross, ps)
+			urgent = urgent || level == liquidationAction
+			continue
+		}
+
+		if ps.AutoAddMargin {
+			log.Printf("📍 %s %s: 已经配置自动追加保证金", ex.GetName(), ps.Symbol)
+			continue
 		}
-	case "ByBit":
-		if bybitPositions, ok := positions.(*model.PositionList); ok {
-			for i := range bybitPositions.List {
-				ps := bybitPositions.List[i]
-				if ps.AutoAddMargin == 1 {
-					log.Printf(fmt.Sprintf("📍 ByBit %s: %s", ps.Symbol, "已经配置自动追加保证金"))
-					continue
-				}
 
-				go func(symbol string, amount float64) {
-					// 调用 AddMargin，设置自动追加保证金为 1
-					msg := exchange.AddMargin(symbol, 1)
-					c.M.SendTelegramMessage(fmt.Sprintf("📍 ByBit %s: %s", symbol, msg))
-				}(ps.Symbol, 0)
+		// 双向持仓需要适配器能指定方向，否则可能调整到另一侧仓位
+		if ps.Hedged && !c.supports(ex, exchange.CapHedgeMode) {
+			continue
+		}
+
+		if am, ok := ex.(exchange.AutoMarginer); ok && am.AutoAddEnabled(ps.Symbol) && c.supports(ex, exchange.CapAutoAddMargin) {
+			go func(ps model.Position) {
+				var result *model.MarginResult
+				// 开启自动追加是幂等操作，可以按网络错误重试
+				err := c.call(ex, "set auto add margin", func() (err error) {
+					result, err = am.SetAutoAddMargin(ps)
+					return err
+				})
+				c.M.SendTelegramMessage(fmt.Sprintf("📍 %s %s: %s", ex.GetName(), legName(ps), marginMessage("Auto add margin", result, err)))
+			}(ps)
+			continue
+		}
+
+		if ps.MarginRatio > c.dangerThreshold(ex) || level == liquidationAction {
+			if !c.supports(ex, exchange.CapAddMargin) {
+				continue
+			}
+			addAmount := roundUpAmount(ps.InitialMargin*c.addMultiple(ex), ps.MarginAsset)
+			reason := "Margin ratio exceeds threshold"
+			if level == liquidationAction {
+				reason = "Too close to liquidation"
 			}
+			log.Printf("⚠️ %s! Adding margin: Exchange=%s, Symbol=%s, Amount=%.6f %s\n",
+				reason, ex.GetName(), legName(ps), addAmount, ps.MarginAsset)
+
+			key := ex.GetName() + "|" + legName(ps)
+			if _, loaded := c.adding.LoadOrStore(key, struct{}{}); loaded {
+				log.Printf("📍 %s %s: 正在追加保证金，跳过\n", ex.GetName(), legName(ps))
+				continue
+			}
+
+			go func(ps model.Position, amount float64) {
+				defer c.adding.Delete(key)
+				c.addMargin(ex, ps, amount)
+			}(ps, addAmount)
+			continue
+		}
+
+		// 接近强平价时不回收保证金
+		if level != liquidationSafe {
+			c.calm.Delete(ex.GetName() + "|" + legName(ps))
+			continue
 		}
+		c.checkReclaim(ex, ps)
+	}
+
+	if len(cross) > 0 {
+		key := ex.GetName() + "|account"
+		if _, loaded := c.adding.LoadOrStore(key, struct{}{}); !loaded {
+			go func() {
+				defer c.adding.Delete(key)
+				c.checkAccount(ex, cross, urgent)
+			}()
+		}
+	}
+}
+
+// dangerThreshold 账户配置了阈值时优先使用，否则使用全局阈值
+func (c *Controller) dangerThreshold(ex exchange.Exchange) float64 {
+	if threshold := c.M.Confs[ex].DangerThreshold; threshold > 0 {
+		return threshold
+	}
+	return c.Conf.Monitor.DangerThreshold
+}
+
+// addMultiple 账户配置了追加倍数时优先使用，否则使用全局倍数
+func (c *Controller) addMultiple(ex exchange.Exchange) float64 {
+	if multiple := c.M.Confs[ex].AddMultiple; multiple > 0 {
+		return multiple
+	}
+	return c.Conf.AddMultiple
+}
+
+// addMargin 按钱包可用余额调整金额后追加保证金，并通知结果
+func (c *Controller) addMargin(ex exchange.Exchange, ps model.Position, amount float64) {
+	amount, ok := c.fitBalance(ex, ps, amount)
+	if !ok {
+		return
+	}
+	var result *model.MarginResult
+	err := c.callOnce(ex, "add margin", func() (err error) {
+		result, err = ex.AddMargin(ps, amount)
+		return err
+	})
+	if err != nil {
+		log.Printf("❌ Margin add failed: Exchange=%s, Symbol=%s, Amount=%.4f, Error=%v\n", ex.GetName(), legName(ps), amount, err)
+	}
+	c.M.SendTelegramMessage(fmt.Sprintf("📍 %s %s: %s", ex.GetName(), legName(ps), marginMessage("Margin add", result, err)))
+}
+
+// legName 持仓名称，双向持仓时带上方向以区分多空两个仓位
+func legName(ps model.Position) string {
+	if ps.Hedged {
+		return ps.Symbol + " " + ps.Side
+	}
+	return ps.Symbol
+}
+
+// marginMessage 根据保证金操作结果生成通知内容
+func marginMessage(action string, result *model.MarginResult, err error) string {
+	if err != nil {
+		if result != nil && result.Code != "" {
+			return fmt.Sprintf("❌ %s failed [code %s]: %v", action, result.Code, err)
+		}
+		return fmt.Sprintf("❌ %s failed: %v", action, err)
+	}
+	if result.Status == model.MarginStatusUnchanged {
+		return fmt.Sprintf("⚠️ %s unchanged (可能已是目标状态)", action)
+	}
+
+	msg := fmt.Sprintf("✅ %s succeeded", action)
+	if result.Amount > 0 {
+		msg += fmt.Sprintf(": %g", result.Amount)
+	}
+	if result.NewMargin > 0 {
+		msg += fmt.Sprintf(", margin now %g", result.NewMargin)
+	}
+	if result.TxID != "" {
+		msg += fmt.Sprintf(", tx %s", result.TxID)
 	}
+	return msg
 }
@@ -0,0 +1,158 @@
+package margin_monitor
+
+import (
+	"fmt"
+	"log"
+	"margin_monitor/config"
+	"margin_monitor/exchange"
+	"math"
+	"sync"
+	"time"
+)
+
+// fundingQuota 记录每个交易所当天已划转的金额
+type fundingQuota struct {
+	mu   sync.Mutex
+	day  map[string]string
+	used map[string]float64
+}
+
+// reserve 在每日额度内预留划转金额，返回实际可划转的金额
+func (q *fundingQuota) reserve(name string, amount float64, limit float64) float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.day == nil {
+		q.day = make(map[string]string)
+		q.used = make(map[string]float64)
+	}
+	today := time.Now().Format("2006-01-02")
+	if q.day[name] != today {
+		q.day[name] = today
+		q.used[name] = 0
+	}
+	amount = math.Min(amount, limit-q.used[name])
+	if amount <= 0 {
+		return 0
+	}
+	q.used[name] += amount
+	return amount
+}
+
+// release 归还未使用的额度
+func (q *fundingQuota) release(name string, amount float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.used[name] = math.Max(q.used[name]-amount, 0)
+}
+
+// fund 从现货 / 资金账户向合约钱包划转 amount，受每日额度限制，返回实际划转的金额
+func (c *Controller) fund(ex exchange.Exchange, asset string, amount float64) float64 {
+	conf := c.M.Confs[ex].Funding
+	if !conf.Enabled {
+		return 0
+	}
+	if sub := c.M.Confs[ex].Sub; sub != nil {
+		return c.fundSubAccount(ex, *sub, asset, amount)
+	}
+	if !c.supports(ex, exchange.CapTransfer) {
+		return 0
+	}
+	transferer, ok := ex.(exchange.Transferer)
+	if !ok {
+		return 0
+	}
+	if conf.DailyLimit <= 0 {
+		log.Printf("⚠️ %s funding dailyLimit not set, skip funding\n", ex.GetName())
+		return 0
+	}
+
+	amount = roundUpAmount(amount, asset)
+	quota := c.funding.reserve(ex.GetName(), amount, conf.DailyLimit)
+	if quota <= 0 {
+		msg := fmt.Sprintf("❌ %s: daily funding limit %.2f reached, cannot transfer %.4f %s to futures wallet",
+			ex.GetName(), conf.DailyLimit, amount, asset)
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+		return 0
+	}
+
+	wallets := conf.From
+	if len(wallets) == 0 {
+		wallets = transferer.FundingWallets()
+	}
+
+	var transferred float64
+	for _, wallet := range wallets {
+		if transferred >= quota {
+			break
+		}
+		balance, err := transferer.FetchWalletBalance(wallet, asset)
+		if err != nil {
+			log.Printf("⚠️ %s fetch %s balance error: %v\n", ex.GetName(), wallet, err)
+			continue
+		}
+		size := math.Min(quota-transferred, roundDownAmount(balance.Available, asset))
+		if size <= 0 {
+			continue
+		}
+
+		result, err := transferer.TransferToFutures(wallet, asset, size)
+		if err != nil {
+			msg := fmt.Sprintf("❌ %s: transfer %.4f %s from %s to futures failed: %v", ex.GetName(), size, asset, wallet, err)
+			log.Println(msg)
+			c.M.SendTelegramMessage(msg)
+			continue
+		}
+		transferred += result.Amount
+		msg := fmt.Sprintf("💸 %s: transferred %.4f %s from %s to futures wallet, tx %s",
+			ex.GetName(), result.Amount, asset, wallet, result.TxID)
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+	}
+
+	c.funding.release(ex.GetName(), quota-transferred)
+	return transferred
+}
+
+// fundSubAccount 由主账户向子账户合约钱包划转 amount，额度按主账户计算
+func (c *Controller) fundSubAccount(ex exchange.Exchange, sub config.SubAccount, asset string, amount float64) float64 {
+	master := c.M.Masters[ex]
+	if !c.supports(master, exchange.CapSubAccountTransfer) {
+		return 0
+	}
+	funder, ok := master.(exchange.SubAccountFunder)
+	if !ok {
+		return 0
+	}
+	conf := c.M.Confs[master].Funding
+	if conf.DailyLimit <= 0 {
+		log.Printf("⚠️ %s funding dailyLimit not set, skip funding\n", master.GetName())
+		return 0
+	}
+
+	amount = roundUpAmount(amount, asset)
+	quota := c.funding.reserve(master.GetName(), amount, conf.DailyLimit)
+	if quota <= 0 {
+		msg := fmt.Sprintf("❌ %s: daily funding limit %.2f reached, cannot transfer %.4f %s to sub-account %s",
+			master.GetName(), conf.DailyLimit, amount, asset, sub.Name)
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+		return 0
+	}
+
+	result, err := funder.FundSubAccount(sub, asset, quota)
+	if err != nil {
+		c.funding.release(master.GetName(), quota)
+		msg := fmt.Sprintf("❌ %s: transfer %.4f %s from master to sub-account futures wallet failed: %v", ex.GetName(), quota, asset, err)
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+		return 0
+	}
+	c.funding.release(master.GetName(), quota-result.Amount)
+	msg := fmt.Sprintf("💸 %s: transferred %.4f %s from master %s to sub-account futures wallet, tx %s",
+		ex.GetName(), result.Amount, asset, master.GetName(), result.TxID)
+	log.Println(msg)
+	c.M.SendTelegramMessage(msg)
+	return result.Amount
+}
@@ -0,0 +1,165 @@
+package margin_monitor
+
+import (
+	"fmt"
+	"log"
+	"margin_monitor/exchange"
+	"margin_monitor/model"
+	"math"
+	"time"
+)
+
+const (
+	defaultActionPercent = 2.0
+	defaultATRInterval   = "1h"
+	defaultATRPeriod     = 14
+	// atrTTL ATR 缓存时间，K 线周期内变化不大
+	atrTTL = 5 * time.Minute
+	// liquidationAlertInterval 同一持仓强平距离告警的最小间隔，升级为紧急时立即告警
+	liquidationAlertInterval = 10 * time.Minute
+)
+
+// liquidationLevel 强平距离等级
+type liquidationLevel int
+
+const (
+	liquidationSafe liquidationLevel = iota
+	liquidationWarn
+	liquidationAction
+)
+
+// liquidationDistance 标记价格到强平价的距离
+type liquidationDistance struct {
+	Percent float64 // 占标记价格的百分比，已越过强平价时为 0
+	ATR     float64 // ATR 倍数，无法计算 ATR 时为 0
+}
+
+func (d liquidationDistance) String() string {
+	if d.ATR > 0 {
+		return fmt.Sprintf("%.2f%% / %.2f ATR", d.Percent, d.ATR)
+	}
+	return fmt.Sprintf("%.2f%%", d.Percent)
+}
+
+type liquidationAlert struct {
+	level liquidationLevel
+	at    time.Time
+}
+
+type atrEntry struct {
+	value   float64
+	expires time.Time
+}
+
+// checkLiquidation 计算持仓到强平价的距离，低于告警阈值时通知，返回距离等级
+func (c *Controller) checkLiquidation(ex exchange.Exchange, ps model.Position) liquidationLevel {
+	key := ex.GetName() + "|" + legName(ps)
+	if ps.LiquidationPrice <= 0 || ps.MarkPrice <= 0 {
+		c.liquidationAlerts.Delete(key)
+		return liquidationSafe
+	}
+	conf := c.Conf.Monitor.Liquidation
+	distance := liquidationDistance{Percent: liquidationPercent(ps)}
+	if conf.WarnATR > 0 || conf.ActionATR > 0 {
+		if atr := c.atr(ex, ps.Symbol); atr > 0 {
+			distance.ATR = math.Abs(ps.MarkPrice-ps.LiquidationPrice) / atr
+		}
+	}
+
+	actionPercent := conf.ActionPercent
+	if actionPercent == 0 {
+		actionPercent = defaultActionPercent
+	}
+	below := func(value float64, threshold float64) bool {
+		return threshold > 0 && value < threshold
+	}
+	level := liquidationSafe
+	switch {
+	case below(distance.Percent, actionPercent) || (distance.ATR > 0 && below(distance.ATR, conf.ActionATR)):
+		level = liquidationAction
+	case below(distance.Percent, conf.WarnPercent) || (distance.ATR > 0 && below(distance.ATR, conf.WarnATR)):
+		level = liquidationWarn
+	}
+	if level == liquidationSafe {
+		// 回到安全距离后清除告警记录，再次接近时立即通知
+		c.liquidationAlerts.Delete(key)
+		return level
+	}
+
+	log.Printf("⚠️ %s %s: mark %.6f, liquidation %.6f, distance %s\n",
+		ex.GetName(), legName(ps), ps.MarkPrice, ps.LiquidationPrice, distance)
+	if last, ok := c.liquidationAlerts.Load(key); ok {
+		alert := last.(liquidationAlert)
+		if alert.level >= level && time.Since(alert.at) < liquidationAlertInterval {
+			return level
+		}
+	}
+	c.liquidationAlerts.Store(key, liquidationAlert{level: level, at: time.Now()})
+	prefix := "⚠️"
+	if level == liquidationAction {
+		prefix = "🚨"
+	}
+	c.M.SendTelegramMessage(fmt.Sprintf("%s %s %s: %s from liquidation (mark %.6f, liquidation %.6f), margin ratio %.4f",
+		prefix, ex.GetName(), legName(ps), distance, ps.MarkPrice, ps.LiquidationPrice, ps.MarginRatio))
+	return level
+}
+
+// liquidationPercent 多头强平价在下方、空头在上方，已越过强平价时为 0
+func liquidationPercent(ps model.Position) float64 {
+	distance := ps.MarkPrice - ps.LiquidationPrice
+	if ps.Side == model.SideShort {
+		distance = -distance
+	}
+	return math.Max(distance, 0) / ps.MarkPrice * 100
+}
+
+// atr 返回交易对的平均真实波幅，交易所不支持 K 线或查询失败时返回 0（只按百分比判断）
+func (c *Controller) atr(ex exchange.Exchange, symbol string) float64 {
+	key := ex.GetName() + "|" + symbol
+	if entry, ok := c.atrs.Load(key); ok && time.Now().Before(entry.(atrEntry).expires) {
+		return entry.(atrEntry).value
+	}
+	if !c.supports(ex, exchange.CapCandles) {
+		return 0
+	}
+	fetcher, ok := ex.(exchange.CandleFetcher)
+	if !ok {
+		return 0
+	}
+
+	conf := c.Conf.Monitor.Liquidation
+	interval, period := conf.ATRInterval, conf.ATRPeriod
+	if interval == "" {
+		interval = defaultATRInterval
+	}
+	if period <= 0 {
+		period = defaultATRPeriod
+	}
+	// K 线查询失败不计入熔断，避免影响保证金操作，失败结果同样缓存
+	var value float64
+	candles, err := fetcher.FetchCandles(symbol, interval, period+1)
+	if err != nil {
+		log.Printf("%s fetch candles %s error: %v\n", ex.GetName(), symbol, err)
+	} else {
+		value = averageTrueRange(candles, period)
+	}
+	c.atrs.Store(key, atrEntry{value: value, expires: time.Now().Add(atrTTL)})
+	return value
+}
+
+// averageTrueRange 最近 period 根 K 线真实波幅的平均值，第一根只用于取前收盘价
+func averageTrueRange(candles []model.Candle, period int) float64 {
+	if len(candles) < 2 {
+		return 0
+	}
+	if len(candles) > period+1 {
+		candles = candles[len(candles)-period-1:]
+	}
+	var sum float64
+	for i := 1; i < len(candles); i++ {
+		prevClose := candles[i-1].Close
+		high, low := candles[i].High, candles[i].Low
+		sum += math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+	}
+	return sum / float64(len(candles)-1)
+}
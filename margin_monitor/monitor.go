@@ -14,21 +14,78 @@ import (
 
 type Monitor struct {
 	Exchange []exchange.Exchange
+	Confs    map[exchange.Exchange]config.Exchange   // 每个交易所实例对应的配置
+	Masters  map[exchange.Exchange]exchange.Exchange // 子账户实例 -> 主账户实例
 	TGBot    *tgbotapi.BotAPI
 	ChatID   int64
 }
 
 func NewMonitor(conf *config.Config) (*Monitor, error) {
 	ecs := make([]exchange.Exchange, 0)
+	confs := make(map[exchange.Exchange]config.Exchange)
+	masters := make(map[exchange.Exchange]exchange.Exchange)
+	names := make(map[string]bool)
+	register := func(ex exchange.Exchange, ec config.Exchange) error {
+		if names[ex.GetName()] {
+			return fmt.Errorf("duplicate exchange account %s, set a distinct label", ex.GetName())
+		}
+		names[ex.GetName()] = true
+		ecs = append(ecs, ex)
+		confs[ex] = ec
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, ec := range conf.Exchange {
+		counts[ec.Name]++
+	}
+	labels := make(map[string]bool)
+	for _, ec := range conf.Exchange {
+		labels[ec.Name+"|"+ec.Label] = true
+	}
 	for i := range conf.Exchange {
-		switch conf.Exchange[i].Name {
-		case "bybit":
-			ecs = append(ecs, exchange.NewByBit(conf.Exchange[i].ExchangeKey, conf.Exchange[i].ExchangeSecret, conf.Proxy))
-		case "binance":
-			ecs = append(ecs, exchange.NewBinance(conf.Exchange[i].ExchangeKey, conf.Exchange[i].ExchangeSecret, conf.Proxy))
+		ec := conf.Exchange[i]
+		// 同一交易所配置多个账户却未设置标签时自动生成，避免名称冲突导致启动失败
+		if ec.Label == "" && counts[ec.Name] > 1 {
+			ec.Label = defaultLabel(ec, i, labels)
+			labels[ec.Name+"|"+ec.Label] = true
+			log.Printf("⚠️ multiple %s accounts configured, exchange[%d] has no label, using %q\n", ec.Name, i, ec.Label)
+		}
+		master, err := newExchange(ec, conf.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		if err := register(master, ec); err != nil {
+			return nil, err
+		}
+
+		// 子账户使用各自的 API key 单独监控，资金不足时由主账户划转
+		for _, sub := range ec.SubAccounts {
+			if sub.ExchangeKey == "" || sub.ExchangeSecret == "" {
+				return nil, fmt.Errorf("sub-account %s of %s requires its own exchangeKey and exchangeSecret", sub.Name, master.GetName())
+			}
+			subConf := ec.ForSubAccount(sub)
+			ex, err := newExchange(subConf, conf.Proxy)
+			if err != nil {
+				return nil, err
+			}
+			if err := register(ex, subConf); err != nil {
+				return nil, err
+			}
+			masters[ex] = master
 		}
 	}
 
+	// 未配置 Telegram 时（如离线模拟）只输出日志
+	if conf.Telegram.BotToken == "" {
+		log.Println("Telegram bot token is empty, messages will only be logged")
+		return &Monitor{
+			Exchange: ecs,
+			Confs:    confs,
+			Masters:  masters,
+			ChatID:   conf.Telegram.ChatID,
+		}, nil
+	}
+
 	var transport *http.Transport
 	if conf.Proxy != "" {
 		proxyURL, err := url.Parse(conf.Proxy)
@@ -54,14 +111,54 @@ func NewMonitor(conf *config.Config) (*Monitor, error) {
 
 	return &Monitor{
 		Exchange: ecs,
+		Confs:    confs,
+		Masters:  masters,
 		TGBot:    bot,
 		ChatID:   conf.Telegram.ChatID,
 	}, nil
 }
 
+// defaultLabel 默认使用 API key 后 4 位，key 过短或与已有标签重复时使用配置序号
+func defaultLabel(ec config.Exchange, index int, used map[string]bool) string {
+	if key := ec.ExchangeKey; len(key) >= 4 {
+		if label := key[len(key)-4:]; !used[ec.Name+"|"+label] {
+			return label
+		}
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// newExchange 按配置名称创建交易所实例，未知名称按 ccxt 交易所 id 处理
+func newExchange(ec config.Exchange, proxy string) (exchange.Exchange, error) {
+	switch ec.Name {
+	case "bybit":
+		return exchange.NewByBit(ec, proxy), nil
+	case "binance":
+		return exchange.NewBinance(ec, proxy), nil
+	case "okx":
+		return exchange.NewOKX(ec, proxy), nil
+	case "bitget":
+		return exchange.NewBitget(ec, proxy), nil
+	case "hyperliquid":
+		return exchange.NewHyperliquid(ec, proxy), nil
+	case "sim":
+		ex, err := exchange.NewSim(ec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize sim exchange: %w", err)
+		}
+		return ex, nil
+	default:
+		ex, err := exchange.NewCCXT(ec, proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize exchange %s: %w", ec.Name, err)
+		}
+		return ex, nil
+	}
+}
+
 func (m *Monitor) SendTelegramMessage(message string) {
 	if m.TGBot == nil {
-		log.Println("Telegram bot is not initialized")
+		log.Printf("Telegram bot is not initialized, message: %s", message)
 		return
 	}
 
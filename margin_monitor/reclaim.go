@@ -0,0 +1,94 @@
+package margin_monitor
+
+import (
+	"fmt"
+	"log"
+	"margin_monitor/exchange"
+	"margin_monitor/model"
+	"math"
+	"strings"
+	"time"
+)
+
+// checkReclaim 逐仓持仓保证金率持续低于安全阈值达到配置时长后，减少保证金直到目标保证金率
+func (c *Controller) checkReclaim(ex exchange.Exchange, ps model.Position) {
+	conf := c.Conf.Monitor.Reclaim
+	if conf.SafeThreshold <= 0 || conf.TargetRatio <= 0 || conf.Duration <= 0 {
+		return
+	}
+	key := ex.GetName() + "|" + legName(ps)
+	// 保证金率为 0 说明交易所没有返回维持保证金，无法计算可回收金额
+	if ps.MarginRatio <= 0 || ps.MarginRatio >= conf.SafeThreshold {
+		c.calm.Delete(key)
+		return
+	}
+	since, _ := c.calm.LoadOrStore(key, time.Now())
+	if time.Since(since.(time.Time)) < time.Duration(conf.Duration)*time.Second {
+		return
+	}
+
+	// 保证金余额 = 维持保证金 / 保证金率，回收到目标保证金率时的余额之差即可回收金额
+	balance := ps.MaintenanceMargin / ps.MarginRatio
+	amount := balance - ps.MaintenanceMargin/conf.TargetRatio
+	// 交易所不允许减到开仓所需的初始保证金以下，最多回收超出的部分
+	if ps.Leverage > 0 {
+		amount = math.Min(amount, balance-ps.Size*ps.MarkPrice/ps.Leverage)
+	}
+	amount = roundDownAmount(amount, ps.MarginAsset)
+	if amount <= 0 || !c.supports(ex, exchange.CapReduceMargin) {
+		return
+	}
+	if _, loaded := c.adding.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	log.Printf("♻️ Margin ratio below safe threshold for %ds, reclaiming margin: Exchange=%s, Symbol=%s, MarginRatio=%.4f, Amount=%.6f %s\n",
+		conf.Duration, ex.GetName(), legName(ps), ps.MarginRatio, amount, ps.MarginAsset)
+
+	go func() {
+		defer c.adding.Delete(key)
+		// 无论成败都重新计时，避免每轮重复请求
+		defer c.calm.Delete(key)
+		var result *model.MarginResult
+		err := c.callOnce(ex, "reduce margin", func() (err error) {
+			result, err = ex.ReduceMargin(ps, amount)
+			return err
+		})
+		if err != nil {
+			log.Printf("❌ Margin reduce failed: Exchange=%s, Symbol=%s, Amount=%.4f, Error=%v\n", ex.GetName(), legName(ps), amount, err)
+		}
+		c.M.SendTelegramMessage(fmt.Sprintf("♻️ %s %s: %s", ex.GetName(), legName(ps), marginMessage("Margin reclaim", result, err)))
+	}()
+}
+
+// validateReclaim 开启回收时目标保证金率必须介于安全阈值与每个账户的危险阈值之间，
+// 否则回收后立即超过危险阈值，与追加保证金来回震荡
+func (c *Controller) validateReclaim() error {
+	conf := c.Conf.Monitor.Reclaim
+	if conf.SafeThreshold <= 0 || conf.TargetRatio <= 0 || conf.Duration <= 0 {
+		return nil
+	}
+	if conf.TargetRatio <= conf.SafeThreshold {
+		return fmt.Errorf("reclaim targetRatio %.4f must be greater than safeThreshold %.4f", conf.TargetRatio, conf.SafeThreshold)
+	}
+	for _, ex := range c.M.Exchange {
+		if threshold := c.dangerThreshold(ex); conf.TargetRatio >= threshold {
+			return fmt.Errorf("reclaim targetRatio %.4f must be less than %s dangerThreshold %.4f", conf.TargetRatio, ex.GetName(), threshold)
+		}
+	}
+	return nil
+}
+
+// pruneCalm 清理已平仓持仓的低风险计时
+func (c *Controller) pruneCalm(ex exchange.Exchange, positions []model.Position) {
+	open := make(map[string]bool, len(positions))
+	for _, ps := range positions {
+		open[ex.GetName()+"|"+legName(ps)] = true
+	}
+	prefix := ex.GetName() + "|"
+	c.calm.Range(func(key, _ interface{}) bool {
+		if k := key.(string); strings.HasPrefix(k, prefix) && !open[k] {
+			c.calm.Delete(k)
+		}
+		return true
+	})
+}
@@ -0,0 +1,134 @@
+package margin_monitor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"margin_monitor/exchange"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	retryAttempts   = 3                      // 网络错误最多尝试次数
+	retryBaseDelay  = 500 * time.Millisecond // 首次重试等待
+	retryMaxDelay   = 5 * time.Second
+	breakerFailures = 3           // 连续失败次数达到后熔断
+	breakerCooldown = time.Minute // 熔断后等待多久放行一次探测请求
+)
+
+var errBreakerOpen = errors.New("circuit breaker open")
+
+// breaker 单个交易所的熔断器，连续失败（网络 / 鉴权）达到阈值后暂停调用
+// 业务错误说明交易所可用，不计入失败
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time
+	probing  bool
+}
+
+// allow 熔断期间拒绝调用，冷却后每次只放行一个探测请求
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < breakerCooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record 记录调用结果，返回熔断器是否刚打开 / 刚关闭
+func (b *breaker) record(err error) (opened bool, closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil || exchange.Classify(err) == exchange.ErrorBusiness {
+		closed = b.open
+		b.open = false
+		b.failures = 0
+		return false, closed
+	}
+
+	b.failures++
+	if b.open {
+		// 探测失败，重新开始冷却
+		b.openedAt = time.Now()
+		return false, false
+	}
+	if b.failures >= breakerFailures {
+		b.open = true
+		b.openedAt = time.Now()
+		return true, false
+	}
+	return false, false
+}
+
+func (c *Controller) breaker(ex exchange.Exchange) *breaker {
+	b, _ := c.breakers.LoadOrStore(ex, &breaker{})
+	return b.(*breaker)
+}
+
+// call 调用交易所只读接口（持仓、余额、账户风险等），网络错误按指数退避加随机抖动重试，鉴权与业务错误直接返回
+// 熔断器打开与关闭时各通知一次
+func (c *Controller) call(ex exchange.Exchange, op string, fn func() error) error {
+	return c.invoke(ex, op, fn, func(err error) bool {
+		return exchange.Classify(err) == exchange.ErrorNetwork
+	})
+}
+
+// callOnce 调用会改变账户状态的接口（追加 / 减少保证金、下单），超时等网络错误可能已被交易所执行，
+// 只有确定请求没有发出（连接失败）时才重试，避免重复追加或重复减仓
+func (c *Controller) callOnce(ex exchange.Exchange, op string, fn func() error) error {
+	return c.invoke(ex, op, fn, exchange.NotSent)
+}
+
+// invoke 经过熔断器调用 fn，retryable 返回 true 的错误按退避重试
+func (c *Controller) invoke(ex exchange.Exchange, op string, fn func() error, retryable func(error) bool) error {
+	b := c.breaker(ex)
+	if !b.allow() {
+		return fmt.Errorf("%s %s skipped: %w", ex.GetName(), op, errBreakerOpen)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			break
+		}
+		if !retryable(err) || attempt >= retryAttempts {
+			break
+		}
+		delay := backoff(attempt)
+		log.Printf("🔁 %s %s %s error, retry %d/%d in %s: %v\n", ex.GetName(), op, exchange.Classify(err), attempt, retryAttempts-1, delay, err)
+		time.Sleep(delay)
+	}
+
+	opened, closed := b.record(err)
+	switch {
+	case opened:
+		msg := fmt.Sprintf("🚨 %s: circuit breaker open after %d failures (%s %s error: %v), pausing calls for %s",
+			ex.GetName(), breakerFailures, op, exchange.Classify(err), err, breakerCooldown)
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+	case closed:
+		msg := fmt.Sprintf("✅ %s: circuit breaker closed, %s succeeded", ex.GetName(), op)
+		log.Println(msg)
+		c.M.SendTelegramMessage(msg)
+	}
+	return err
+}
+
+// backoff 第 attempt 次重试前的等待时间，指数增长并在 [d/2, d) 内随机抖动
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << (attempt - 1)
+	if d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
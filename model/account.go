@@ -0,0 +1,11 @@
+package model
+
+// AccountRisk 账户级（全仓）风险，全仓持仓共用账户保证金
+type AccountRisk struct {
+	Asset             string  `json:"asset"`
+	Equity            float64 `json:"equity"` // 保证金余额，含未实现盈亏
+	InitialMargin     float64 `json:"initialMargin"`
+	MaintenanceMargin float64 `json:"maintenanceMargin"`
+	Available         float64 `json:"available"`
+	MarginRatio       float64 `json:"marginRatio"` // 维持保证金 / 保证金余额，与持仓口径一致
+}
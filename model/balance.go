@@ -0,0 +1,8 @@
+package model
+
+// Balance 合约账户单个币种的余额
+type Balance struct {
+	Asset     string  `json:"asset"`
+	Total     float64 `json:"total"`     // 钱包余额
+	Available float64 `json:"available"` // 可用于追加保证金 / 划转的余额
+}
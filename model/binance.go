@@ -0,0 +1,12 @@
+package model
+
+// BinanceExchangeInfo Binance /fapi/v1/exchangeInfo 原始响应，只保留数量过滤器
+type BinanceExchangeInfo struct {
+	Symbols []struct {
+		Symbol  string `json:"symbol"`
+		Filters []struct {
+			FilterType string `json:"filterType"`
+			StepSize   string `json:"stepSize"` // LOT_SIZE / MARKET_LOT_SIZE
+		} `json:"filters"`
+	} `json:"symbols"`
+}
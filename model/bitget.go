@@ -0,0 +1,28 @@
+package model
+
+// BitgetPosition Bitget /api/v2/mix/position/all-position 原始持仓
+type BitgetPosition struct {
+	Symbol           string `json:"symbol"`
+	MarginCoin       string `json:"marginCoin"`
+	HoldSide         string `json:"holdSide"`
+	Total            string `json:"total"`
+	OpenPriceAvg     string `json:"openPriceAvg"`
+	MarkPrice        string `json:"markPrice"`
+	LiquidationPrice string `json:"liquidationPrice"`
+	MarginMode       string `json:"marginMode"`
+	MarginSize       string `json:"marginSize"`
+	Leverage         string `json:"leverage"`
+	UnrealizedPL     string `json:"unrealizedPL"`
+	KeepMarginRate   string `json:"keepMarginRate"`
+	MarginRatio      string `json:"marginRatio"`
+	PosMode          string `json:"posMode"`
+}
+
+// BitgetAccount Bitget /api/v2/mix/account/accounts 原始账户
+type BitgetAccount struct {
+	MarginCoin    string `json:"marginCoin"`
+	Available     string `json:"available"`
+	AccountEquity string `json:"accountEquity"`
+	// CrossedRiskRate 全仓风险率，维持保证金 / 权益
+	CrossedRiskRate string `json:"crossedRiskRate"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// Bracket 杠杆分层（Binance）/ 风险限额档位（ByBit），名义价值不超过 Cap 时使用该档
+// 维持保证金 = 名义价值 × MaintMarginRate - MaintAmount
+type Bracket struct {
+	Cap             float64 `json:"cap"`
+	MaintMarginRate float64 `json:"maintMarginRate"`
+	MaintAmount     float64 `json:"maintAmount"` // 速算额，Binance cum / ByBit mmDeduction
+	MaxLeverage     float64 `json:"maxLeverage"`
+}
+
+// BinanceLeverageBracket Binance /fapi/v1/leverageBracket 原始响应
+type BinanceLeverageBracket struct {
+	Symbol   string `json:"symbol"`
+	Brackets []struct {
+		Bracket          int     `json:"bracket"`
+		InitialLeverage  float64 `json:"initialLeverage"`
+		NotionalCap      float64 `json:"notionalCap"`
+		NotionalFloor    float64 `json:"notionalFloor"`
+		MaintMarginRatio float64 `json:"maintMarginRatio"`
+		Cum              float64 `json:"cum"`
+	} `json:"brackets"`
+}
+
+// ByBitRiskLimitList ByBit /v5/market/risk-limit 原始响应
+type ByBitRiskLimitList struct {
+	Category string `json:"category"`
+	List     []struct {
+		Id                int     `json:"id"`
+		Symbol            string  `json:"symbol"`
+		RiskLimitValue    string  `json:"riskLimitValue"`
+		MaintenanceMargin string  `json:"maintenanceMargin"`
+		InitialMargin     string  `json:"initialMargin"`
+		IsLowestRisk      float64 `json:"isLowestRisk"`
+		MaxLeverage       string  `json:"maxLeverage"`
+		MmDeduction       string  `json:"mmDeduction"`
+	} `json:"list"`
+	NextPageCursor string `json:"nextPageCursor"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// Candle K 线，用于计算 ATR
+type Candle struct {
+	Time  int64   `json:"time"` // 开盘时间（毫秒）
+	Open  float64 `json:"open"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Close float64 `json:"close"`
+}
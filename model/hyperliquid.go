@@ -0,0 +1,43 @@
+package model
+
+// HyperliquidClearinghouseState Hyperliquid info clearinghouseState 返回
+type HyperliquidClearinghouseState struct {
+	AssetPositions []struct {
+		Type     string              `json:"type"`
+		Position HyperliquidPosition `json:"position"`
+	} `json:"assetPositions"`
+	MarginSummary              HyperliquidMarginSummary `json:"marginSummary"`
+	CrossMarginSummary         HyperliquidMarginSummary `json:"crossMarginSummary"`
+	CrossMaintenanceMarginUsed string                   `json:"crossMaintenanceMarginUsed"`
+	Withdrawable               string                   `json:"withdrawable"`
+}
+
+type HyperliquidPosition struct {
+	Coin          string `json:"coin"`
+	Szi           string `json:"szi"` // 带符号的持仓数量，负数为空头
+	EntryPx       string `json:"entryPx"`
+	PositionValue string `json:"positionValue"`
+	UnrealizedPnl string `json:"unrealizedPnl"`
+	LiquidationPx string `json:"liquidationPx"`
+	MarginUsed    string `json:"marginUsed"`
+	MaxLeverage   int    `json:"maxLeverage"`
+	Leverage      struct {
+		Type  string `json:"type"` // isolated / cross
+		Value int    `json:"value"`
+	} `json:"leverage"`
+}
+
+type HyperliquidMarginSummary struct {
+	AccountValue    string `json:"accountValue"`
+	TotalNtlPos     string `json:"totalNtlPos"`
+	TotalMarginUsed string `json:"totalMarginUsed"`
+}
+
+// HyperliquidMeta Hyperliquid info meta 返回，universe 的下标即 asset 编号
+type HyperliquidMeta struct {
+	Universe []struct {
+		Name        string `json:"name"`
+		SzDecimals  int    `json:"szDecimals"`
+		MaxLeverage int    `json:"maxLeverage"`
+	} `json:"universe"`
+}
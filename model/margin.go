@@ -0,0 +1,18 @@
+package model
+
+const (
+	MarginStatusSuccess   = "success"
+	MarginStatusUnchanged = "unchanged" // 交易所返回已是目标状态
+	MarginStatusFailed    = "failed"
+)
+
+// MarginResult 保证金操作结果
+type MarginResult struct {
+	Status    string  `json:"status"`
+	Symbol    string  `json:"symbol"`
+	Amount    float64 `json:"amount"`    // 实际调整的金额
+	NewMargin float64 `json:"newMargin"` // 调整后的仓位保证金，交易所未返回时为 0
+	TxID      string  `json:"txId"`      // 交易所订单号 / 流水号
+	Code      string  `json:"code"`      // 交易所原始错误码
+	Message   string  `json:"message"`
+}
@@ -1,19 +1,55 @@
 package model
 
-type PositionList struct {
-	Category       string     `json:"category"`
-	List           []Position `json:"list"`
-	NextPageCursor string     `json:"nextPageCursor"`
-}
+const (
+	SideLong  = "long"
+	SideShort = "short"
+
+	MarginModeIsolated = "isolated"
+	MarginModeCross    = "cross"
+)
 
+// Position 与交易所无关的统一持仓结构，由各交易所适配器填充
 type Position struct {
+	Symbol            string  `json:"symbol"`
+	Side              string  `json:"side"` // long / short
+	Size              float64 `json:"size"`
+	EntryPrice        float64 `json:"entryPrice"`
+	MarkPrice         float64 `json:"markPrice"`
+	LiquidationPrice  float64 `json:"liquidationPrice"`
+	MarginMode        string  `json:"marginMode"`  // isolated / cross
+	MarginAsset       string  `json:"marginAsset"` // 保证金币种，币本位合约为标的币
+	InitialMargin     float64 `json:"initialMargin"`
+	MaintenanceMargin float64 `json:"maintenanceMargin"`
+	MarginRatio       float64 `json:"marginRatio"` // 维持保证金 / 保证金余额
+	Leverage          float64 `json:"leverage"`
+	UnrealizedPnl     float64 `json:"unrealizedPnl"`
+	AutoAddMargin     bool    `json:"autoAddMargin"`
+	Hedged            bool    `json:"hedged"` // 双向持仓模式下的单边仓位，调整保证金时需指定方向
+}
+
+// PositionEvent 交易所推送的持仓变化
+type PositionEvent struct {
+	Reason    string     // 推送事件类型，如 ACCOUNT_UPDATE / MARGIN_CALL
+	Positions []Position // 推送中已包含完整风险数据的持仓，为空时需要重新拉取
+}
+
+// ByBitPositionList ByBit /v5/position/list 原始响应
+type ByBitPositionList struct {
+	Category       string          `json:"category"`
+	List           []ByBitPosition `json:"list"`
+	NextPageCursor string          `json:"nextPageCursor"`
+}
+
+type ByBitPosition struct {
 	AdlRankIndicator float64 `json:"adlRankIndicator"`
 	AutoAddMargin    float64 `json:"autoAddMargin"`
 	AvgPrice         string  `json:"avgPrice"`
+	Category         string  `json:"category"` // 仅 WebSocket 推送带有
 	BustPrice        string  `json:"bustPrice"`
 	CreatedTime      string  `json:"createdTime"`
 	CumRealisedPnl   string  `json:"cumRealisedPnl"`
 	CurRealisedPnl   string  `json:"curRealisedPnl"`
+	EntryPrice       string  `json:"entryPrice"`
 	IsReduceOnly     bool    `json:"isReduceOnly"`
 	Leverage         string  `json:"leverage"`
 	LiqPrice         string  `json:"liqPrice"`
@@ -39,3 +75,57 @@ type Position struct {
 	UnrealisedPnl    string  `json:"unrealisedPnl"`
 	UpdatedTime      string  `json:"updatedTime"`
 }
+
+// ByBitInstrumentList ByBit /v5/market/instruments-info 原始响应，只保留需要的字段
+type ByBitInstrumentList struct {
+	Category string `json:"category"`
+	List     []struct {
+		Symbol     string `json:"symbol"`
+		BaseCoin   string `json:"baseCoin"`
+		QuoteCoin  string `json:"quoteCoin"`
+		SettleCoin string `json:"settleCoin"`
+		// LotSizeFilter 下单数量必须是 qtyStep 的整数倍
+		LotSizeFilter struct {
+			QtyStep     string `json:"qtyStep"`
+			MinOrderQty string `json:"minOrderQty"`
+		} `json:"lotSizeFilter"`
+	} `json:"list"`
+}
+
+// ByBitAccountInfo ByBit /v5/account/info 原始响应
+type ByBitAccountInfo struct {
+	UnifiedMarginStatus int    `json:"unifiedMarginStatus"`
+	MarginMode          string `json:"marginMode"` // ISOLATED_MARGIN / REGULAR_MARGIN / PORTFOLIO_MARGIN
+}
+
+// ByBitWalletBalance ByBit /v5/account/wallet-balance 原始响应
+type ByBitWalletBalance struct {
+	List []struct {
+		AccountType            string `json:"accountType"`
+		AccountMMRate          string `json:"accountMMRate"`
+		TotalEquity            string `json:"totalEquity"`
+		TotalMarginBalance     string `json:"totalMarginBalance"`
+		TotalInitialMargin     string `json:"totalInitialMargin"`
+		TotalMaintenanceMargin string `json:"totalMaintenanceMargin"`
+		TotalAvailableBalance  string `json:"totalAvailableBalance"`
+		Coin                   []struct {
+			Coin            string `json:"coin"`
+			Equity          string `json:"equity"`
+			WalletBalance   string `json:"walletBalance"`
+			Locked          string `json:"locked"`
+			TotalOrderIM    string `json:"totalOrderIM"`
+			TotalPositionIM string `json:"totalPositionIM"`
+			UnrealisedPnl   string `json:"unrealisedPnl"`
+		} `json:"coin"`
+	} `json:"list"`
+}
+
+// ByBitCoinsBalance ByBit /v5/asset/transfer/query-account-coins-balance 原始响应
+type ByBitCoinsBalance struct {
+	AccountType string `json:"accountType"`
+	Balance     []struct {
+		Coin            string `json:"coin"`
+		WalletBalance   string `json:"walletBalance"`
+		TransferBalance string `json:"transferBalance"`
+	} `json:"balance"`
+}
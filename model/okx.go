@@ -0,0 +1,44 @@
+package model
+
+// OKXPosition OKX /api/v5/account/positions 原始持仓
+type OKXPosition struct {
+	InstId   string `json:"instId"`
+	InstType string `json:"instType"`
+	MgnMode  string `json:"mgnMode"`
+	PosSide  string `json:"posSide"`
+	Pos      string `json:"pos"`
+	AvgPx    string `json:"avgPx"`
+	MarkPx   string `json:"markPx"`
+	LiqPx    string `json:"liqPx"`
+	Lever    string `json:"lever"`
+	Imr      string `json:"imr"`
+	Margin   string `json:"margin"`
+	Mmr      string `json:"mmr"`
+	MgnRatio string `json:"mgnRatio"`
+	Upl      string `json:"upl"`
+	Ccy      string `json:"ccy"`
+}
+
+// OKXMarginBalance OKX /api/v5/account/position/margin-balance 返回
+type OKXMarginBalance struct {
+	InstId  string `json:"instId"`
+	PosSide string `json:"posSide"`
+	Type    string `json:"type"`
+	Amt     string `json:"amt"`
+	Ccy     string `json:"ccy"`
+}
+
+// OKXBalance OKX /api/v5/account/balance 原始响应
+type OKXBalance struct {
+	TotalEq  string `json:"totalEq"`
+	AdjEq    string `json:"adjEq"` // 有效保证金，仅跨币种 / 组合保证金模式
+	Imr      string `json:"imr"`
+	Mmr      string `json:"mmr"`
+	MgnRatio string `json:"mgnRatio"`
+	Details  []struct {
+		Ccy      string `json:"ccy"`
+		Eq       string `json:"eq"`
+		CashBal  string `json:"cashBal"`
+		AvailBal string `json:"availBal"`
+	} `json:"details"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// TransferResult 账户间划转结果
+type TransferResult struct {
+	TxID   string  `json:"txId"`
+	Asset  string  `json:"asset"`
+	Amount float64 `json:"amount"`
+	From   string  `json:"from"` // spot / funding
+	To     string  `json:"to"`
+}